@@ -0,0 +1,64 @@
+package atomic
+
+import "errors"
+
+// ErrFull is returned by send operations on a full [CloseSafeChan] using the
+// [ReturnError] backpressure policy.
+var ErrFull = errors.New("atomic: channel is full")
+
+// Backpressure selects what the send operations of a [CloseSafeChan] do when
+// its buffer is full.
+type Backpressure uint8
+
+const (
+	// Block waits for room in the buffer, this is the default policy.
+	Block Backpressure = iota
+	// DropNewest discards the value being sent.
+	DropNewest
+	// DropOldest evicts the oldest buffered value to make room for the value
+	// being sent. Unbuffered channels have nothing to evict, and behave as
+	// with [DropNewest].
+	DropOldest
+	// ReturnError rejects the value being sent with [ErrFull].
+	ReturnError
+)
+
+// String returns the name of the policy.
+func (b Backpressure) String() string {
+	switch b {
+	case Block:
+		return "Block"
+	case DropNewest:
+		return "DropNewest"
+	case DropOldest:
+		return "DropOldest"
+	case ReturnError:
+		return "ReturnError"
+	default:
+		return "Backpressure(?)"
+	}
+}
+
+// ChanOption configures a [CloseSafeChan], see [MakeCloseSafeChanWith].
+type ChanOption func(*chanConfig)
+
+type chanConfig struct {
+	backpressure Backpressure
+	debug        bool
+}
+
+// WithBackpressure selects the policy applied by send operations when the
+// buffer is full.
+func WithBackpressure(b Backpressure) ChanOption {
+	return func(c *chanConfig) { c.backpressure = b }
+}
+
+// ChanStats holds the counters of a [CloseSafeChan], see
+// [CloseSafeChan.Stats].
+type ChanStats struct {
+	// Dropped is the number of values discarded by the [DropNewest] and
+	// [DropOldest] policies.
+	Dropped uint64
+	// Rejected is the number of values rejected by the [ReturnError] policy.
+	Rejected uint64
+}
@@ -0,0 +1,115 @@
+package atomic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func drain[T any](ch *CloseSafeChan[T]) []T {
+	var values []T
+	for v := range ch.Iter() {
+		values = append(values, v)
+	}
+	return values
+}
+
+func TestBackpressureBlock(t *testing.T) {
+	ch := MakeCloseSafeChanWith[int](1, WithBackpressure(Block))
+
+	require.True(t, ch.Send(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, ch.SendContext(ctx, 2), context.Canceled)
+
+	require.NoError(t, ch.Close())
+	require.Equal(t, []int{1}, drain(ch))
+	require.Equal(t, ChanStats{}, ch.Stats())
+}
+
+func TestBackpressureDropNewest(t *testing.T) {
+	ch := MakeCloseSafeChanWith[int](2, WithBackpressure(DropNewest))
+
+	for i := range 5 {
+		require.True(t, ch.Send(i))
+	}
+
+	require.NoError(t, ch.Close())
+	require.Equal(t, []int{0, 1}, drain(ch))
+	require.Equal(t, ChanStats{Dropped: 3}, ch.Stats())
+}
+
+func TestBackpressureDropOldest(t *testing.T) {
+	ch := MakeCloseSafeChanWith[int](2, WithBackpressure(DropOldest))
+
+	for i := range 5 {
+		require.NoError(t, ch.SendContext(context.Background(), i))
+	}
+
+	require.NoError(t, ch.Close())
+	require.Equal(t, []int{3, 4}, drain(ch))
+	require.Equal(t, ChanStats{Dropped: 3}, ch.Stats())
+}
+
+func TestBackpressureDropOldestUnbuffered(t *testing.T) {
+	ch := MakeCloseSafeChanWith[int](0, WithBackpressure(DropOldest))
+
+	require.True(t, ch.Send(1))
+
+	require.NoError(t, ch.Close())
+	require.Empty(t, drain(ch))
+	require.Equal(t, ChanStats{Dropped: 1}, ch.Stats())
+}
+
+func TestBackpressureReturnError(t *testing.T) {
+	ch := MakeCloseSafeChanWith[int](1, WithBackpressure(ReturnError))
+
+	require.True(t, ch.Send(1))
+	require.False(t, ch.Send(2))
+	require.ErrorIs(t, ch.SendContext(context.Background(), 3), ErrFull)
+
+	require.NoError(t, ch.Close())
+	require.ErrorIs(t, ch.SendContext(context.Background(), 4), ErrClosed)
+	require.Equal(t, []int{1}, drain(ch))
+	require.Equal(t, ChanStats{Rejected: 2}, ch.Stats())
+}
+
+func TestBackpressureDropOldestConcurrent(t *testing.T) {
+	const (
+		nbSenders = 8
+		nbSend    = 1000
+	)
+
+	ch := MakeCloseSafeChanWith[int](4, WithBackpressure(DropOldest))
+
+	done := make(chan int)
+	go func() {
+		var received int
+		for range ch.Iter() {
+			received++
+		}
+		done <- received
+	}()
+
+	start := make(chan struct{})
+	finished := make(chan struct{})
+	for range nbSenders {
+		go func() {
+			<-start
+			for i := range nbSend {
+				require.True(t, ch.Send(i))
+			}
+			finished <- struct{}{}
+		}()
+	}
+	close(start)
+	for range nbSenders {
+		<-finished
+	}
+	require.NoError(t, ch.Close())
+
+	received := <-done
+	require.Equal(t, uint64(nbSenders*nbSend), uint64(received)+ch.Stats().Dropped)
+}
@@ -0,0 +1,90 @@
+package atomic
+
+import (
+	"context"
+	"iter"
+	"time"
+)
+
+// ReceiveBatch receives up to n values from the [CloseSafeChan] instance.
+//
+// It blocks until a first value is received, then returns as soon as n values
+// are received or maxWait elapsed since the first one.
+//
+// The bool result is false once no more batches can be received : the
+// [CloseSafeChan] instance is closed and drained, or ctx is done. The batch may
+// still hold the last values received.
+func (c *CloseSafeChan[T]) ReceiveBatch(ctx context.Context, n int, maxWait time.Duration) ([]T, bool) {
+	return c.AppendBatch(ctx, nil, n, maxWait)
+}
+
+// AppendBatch is like [CloseSafeChan.ReceiveBatch], but appends the batch to
+// dst and returns the extended slice, so that callers can reuse a buffer across
+// batches :
+//
+//	var buf []T
+//	for {
+//		batch, ok := c.AppendBatch(ctx, buf[:0], 64, time.Second)
+//		// ...
+//		buf = batch
+//	}
+func (c *CloseSafeChan[T]) AppendBatch(ctx context.Context, dst []T, n int, maxWait time.Duration) ([]T, bool) {
+	n = max(n, 1)
+	start := len(dst)
+	value, ok, err := c.receive(ctx.Done(), nil)
+	if err != nil || !ok {
+		return dst, false
+	}
+	dst = append(dst, value)
+
+	// Taking the values already buffered first, without arming a timer.
+buffered:
+	for len(dst)-start < n {
+		select {
+		case value, ok := <-c.ch:
+			if !ok {
+				return dst, false
+			}
+			dst = append(dst, value)
+		default:
+			break buffered
+		}
+	}
+	if len(dst)-start == n {
+		return dst, true
+	}
+
+	t := time.NewTimer(maxWait)
+	defer t.Stop()
+	for len(dst)-start < n {
+		value, ok, err := c.receive(ctx.Done(), t.C)
+		if err != nil {
+			// Timing out ends the batch, a done context ends them all.
+			return dst, ctx.Err() == nil
+		}
+		if !ok {
+			return dst, false
+		}
+		dst = append(dst, value)
+	}
+	return dst, true
+}
+
+// Batches returns an iterator ranging on batches of up to n values received
+// from the [CloseSafeChan] instance, see [CloseSafeChan.ReceiveBatch].
+//
+// The iteration stops once the [CloseSafeChan] instance is closed and drained,
+// after yielding the last partial batch.
+func (c *CloseSafeChan[T]) Batches(n int, maxWait time.Duration) iter.Seq[[]T] {
+	return func(yield func([]T) bool) {
+		for {
+			batch, ok := c.ReceiveBatch(context.Background(), n, maxWait)
+			if len(batch) > 0 && !yield(batch) {
+				return
+			}
+			if !ok {
+				return
+			}
+		}
+	}
+}
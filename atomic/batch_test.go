@@ -0,0 +1,94 @@
+package atomic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseSafeChanReceiveBatchFull(t *testing.T) {
+	ch := MakeCloseSafeChan[int](8)
+	for i := range 5 {
+		require.True(t, ch.Send(i))
+	}
+
+	batch, ok := ch.ReceiveBatch(context.Background(), 3, time.Hour)
+	require.True(t, ok)
+	require.Equal(t, []int{0, 1, 2}, batch)
+}
+
+func TestCloseSafeChanReceiveBatchMaxWait(t *testing.T) {
+	ch := MakeCloseSafeChan[int](8)
+	require.True(t, ch.Send(1))
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		_ = ch.Send(2)
+	}()
+
+	start := time.Now()
+	batch, ok := ch.ReceiveBatch(context.Background(), 10, 50*time.Millisecond)
+	require.True(t, ok)
+	require.Equal(t, []int{1, 2}, batch)
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestCloseSafeChanReceiveBatchClosed(t *testing.T) {
+	ch := MakeCloseSafeChan[int](8)
+	require.True(t, ch.Send(1))
+	require.True(t, ch.Send(2))
+	require.NoError(t, ch.Close())
+
+	batch, ok := ch.ReceiveBatch(context.Background(), 10, time.Hour)
+	require.False(t, ok)
+	require.Equal(t, []int{1, 2}, batch)
+
+	batch, ok = ch.ReceiveBatch(context.Background(), 10, time.Hour)
+	require.False(t, ok)
+	require.Empty(t, batch)
+}
+
+func TestCloseSafeChanReceiveBatchContext(t *testing.T) {
+	ch := MakeCloseSafeChan[int](8)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	batch, ok := ch.ReceiveBatch(ctx, 10, time.Hour)
+	require.False(t, ok)
+	require.Empty(t, batch)
+}
+
+func TestCloseSafeChanAppendBatchReuse(t *testing.T) {
+	ch := MakeCloseSafeChan[int](8)
+	for i := range 6 {
+		require.True(t, ch.Send(i))
+	}
+
+	buf := make([]int, 0, 2)
+	batch, ok := ch.AppendBatch(context.Background(), buf, 2, time.Hour)
+	require.True(t, ok)
+	require.Equal(t, []int{0, 1}, batch)
+
+	// AllocsPerRun calls the function twice, a warm-up and a measured run.
+	allocs := testing.AllocsPerRun(1, func() {
+		batch, _ = ch.AppendBatch(context.Background(), batch[:0], 2, time.Hour)
+	})
+	require.Zero(t, allocs)
+	require.Equal(t, []int{4, 5}, batch)
+}
+
+func TestCloseSafeChanBatches(t *testing.T) {
+	ch := MakeCloseSafeChan[int](8)
+	for i := range 7 {
+		require.True(t, ch.Send(i))
+	}
+	require.NoError(t, ch.Close())
+
+	var batches [][]int
+	for batch := range ch.Batches(3, time.Hour) {
+		batches = append(batches, batch)
+	}
+	require.Equal(t, [][]int{{0, 1, 2}, {3, 4, 5}, {6}}, batches)
+}
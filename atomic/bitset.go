@@ -0,0 +1,121 @@
+package atomic
+
+import (
+	"fmt"
+	"iter"
+	"math/bits"
+	"sync/atomic"
+)
+
+// Bitset is a fixed-size set of bits, safe to update from concurrently running
+// goroutines without locking.
+//
+// Each operation is atomic on its own, but operations visiting the whole set,
+// like [Bitset.Count] and [Bitset.All], don't take a snapshot of it.
+type Bitset struct {
+	words []atomic.Uint64
+	n     int
+}
+
+// NewBitset returns a [Bitset] of n bits, all clear.
+func NewBitset(n int) *Bitset {
+	if n < 0 {
+		panic("atomic: negative Bitset size")
+	}
+	return &Bitset{
+		words: make([]atomic.Uint64, (n+63)/64),
+		n:     n,
+	}
+}
+
+// word returns the word holding bit i and the mask selecting it. It panics if
+// i is out of range.
+func (b *Bitset) word(i int) (*atomic.Uint64, uint64) {
+	if uint(i) >= uint(b.n) {
+		panic(fmt.Sprintf("atomic: Bitset index %d out of range [0:%d]", i, b.n))
+	}
+	return &b.words[i/64], 1 << (i % 64)
+}
+
+// Len returns the number of bits of the [Bitset].
+func (b *Bitset) Len() int {
+	return b.n
+}
+
+// Set sets bit i.
+func (b *Bitset) Set(i int) {
+	w, mask := b.word(i)
+	w.Or(mask)
+}
+
+// Clear clears bit i.
+func (b *Bitset) Clear(i int) {
+	w, mask := b.word(i)
+	w.And(^mask)
+}
+
+// Test reports whether bit i is set.
+func (b *Bitset) Test(i int) bool {
+	w, mask := b.word(i)
+	return w.Load()&mask != 0
+}
+
+// TestAndSet sets bit i, and reports whether it was already set.
+func (b *Bitset) TestAndSet(i int) bool {
+	w, mask := b.word(i)
+	return w.Or(mask)&mask != 0
+}
+
+// FindFirstClearAndSet sets the first clear bit, and returns its index. It
+// returns -1 and false if all the bits are set.
+//
+// Goroutines racing on it set distinct bits, so that it can allocate slots :
+//
+//	slot, ok := b.FindFirstClearAndSet()
+//	if !ok {
+//		return errNoSlot
+//	}
+//	defer b.Clear(slot)
+func (b *Bitset) FindFirstClearAndSet() (int, bool) {
+	for i := range b.words {
+		w := &b.words[i]
+		for v := w.Load(); v != ^uint64(0); {
+			bit := bits.TrailingZeros64(^v)
+			index := i*64 + bit
+			if index >= b.n {
+				break
+			}
+			mask := uint64(1) << bit
+			old := w.Or(mask)
+			if old&mask == 0 {
+				return index, true
+			}
+			// Another goroutine set it first, trying the next clear bit.
+			v = old
+		}
+	}
+	return -1, false
+}
+
+// Count returns the number of set bits.
+func (b *Bitset) Count() int {
+	var n int
+	for i := range b.words {
+		n += bits.OnesCount64(b.words[i].Load())
+	}
+	return n
+}
+
+// All returns an iterator ranging on the indexes of the set bits, in
+// increasing order.
+func (b *Bitset) All() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i := range b.words {
+			for v := b.words[i].Load(); v != 0; v &= v - 1 {
+				if !yield(i*64 + bits.TrailingZeros64(v)) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package atomic
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitset(t *testing.T) {
+	b := NewBitset(130)
+	require.Equal(t, 130, b.Len())
+	require.Zero(t, b.Count())
+	require.Empty(t, slices.Collect(b.All()))
+
+	b.Set(0)
+	b.Set(64)
+	b.Set(129)
+	require.True(t, b.Test(64))
+	require.False(t, b.Test(65))
+	require.Equal(t, 3, b.Count())
+	require.Equal(t, []int{0, 64, 129}, slices.Collect(b.All()))
+
+	require.True(t, b.TestAndSet(64))
+	require.False(t, b.TestAndSet(65))
+	b.Clear(64)
+	require.False(t, b.Test(64))
+	require.Equal(t, []int{0, 65, 129}, slices.Collect(b.All()))
+
+	for i := range b.All() {
+		require.Equal(t, 0, i)
+		break
+	}
+
+	require.Panics(t, func() { b.Set(130) })
+	require.Panics(t, func() { b.Test(-1) })
+	require.Panics(t, func() { NewBitset(-1) })
+}
+
+func TestBitsetFindFirstClearAndSet(t *testing.T) {
+	b := NewBitset(66)
+	b.Set(0)
+	b.Set(2)
+	i, ok := b.FindFirstClearAndSet()
+	require.True(t, ok)
+	require.Equal(t, 1, i)
+	i, ok = b.FindFirstClearAndSet()
+	require.True(t, ok)
+	require.Equal(t, 3, i)
+
+	for range 62 {
+		_, ok = b.FindFirstClearAndSet()
+		require.True(t, ok)
+	}
+	require.Equal(t, 66, b.Count())
+	// The bits past the size are never set.
+	i, ok = b.FindFirstClearAndSet()
+	require.False(t, ok)
+	require.Equal(t, -1, i)
+
+	b.Clear(65)
+	i, _ = b.FindFirstClearAndSet()
+	require.Equal(t, 65, i)
+}
+
+func TestBitsetConcurrent(t *testing.T) {
+	const (
+		goroutines = 8
+		size       = 1000
+	)
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		slots []int
+	)
+	b := NewBitset(size)
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				slot, ok := b.FindFirstClearAndSet()
+				if !ok {
+					return
+				}
+				mu.Lock()
+				slots = append(slots, slot)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	// Each slot was allocated exactly once.
+	slices.Sort(slots)
+	require.Len(t, slots, size)
+	for i, slot := range slots {
+		require.Equal(t, i, slot)
+	}
+	require.Equal(t, size, b.Count())
+
+	for i := range size {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%3 != 0 {
+				b.Clear(i)
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, (size+2)/3, b.Count())
+}
+
+func BenchmarkBitsetFindFirstClearAndSet(b *testing.B) {
+	set := NewBitset(1 << 16)
+	for b.Loop() {
+		i, ok := set.FindFirstClearAndSet()
+		if !ok {
+			b.Fatal("full bitset")
+		}
+		set.Clear(i)
+	}
+}
@@ -0,0 +1,223 @@
+package atomic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrSlowConsumer is the cause of the closing of a [Subscription] disconnected
+// by the [SlowConsumerDisconnect] policy, see [Subscription.Err].
+var ErrSlowConsumer = errors.New("atomic: slow consumer disconnected")
+
+// SlowConsumerPolicy selects what [Broadcaster.Publish] does when the buffer of
+// a [Subscription] is full.
+type SlowConsumerPolicy uint8
+
+const (
+	// SlowConsumerBlock waits for room in the buffer of the subscription, this
+	// is the default policy. A single slow subscriber slows down every
+	// publisher.
+	SlowConsumerBlock SlowConsumerPolicy = iota
+	// SlowConsumerDrop discards the value for the subscription.
+	SlowConsumerDrop
+	// SlowConsumerDisconnect unsubscribes the subscription, closing it with
+	// [ErrSlowConsumer] as cause.
+	SlowConsumerDisconnect
+)
+
+// Broadcaster delivers every published value to all of its current
+// subscribers, each of them receiving from its own [CloseSafeChan].
+//
+// Subscribing, unsubscribing, publishing and closing are all safe to call from
+// concurrently running goroutines. The set of subscribers is an immutable
+// slice swapped on each change, so that publishers never hold a lock while
+// delivering values.
+//
+// The zero value is ready to use.
+type Broadcaster[T any] struct {
+	// mu serializes the changes to subs and closed.
+	mu     sync.Mutex
+	subs   atomic.Pointer[[]*Subscription[T]]
+	closed atomic.Bool
+}
+
+// Subscription is the receiving end of a [Broadcaster] subscriber.
+type Subscription[T any] struct {
+	b      *Broadcaster[T]
+	ch     *CloseSafeChan[T]
+	policy SlowConsumerPolicy
+}
+
+// Subscribe registers a new subscriber, buffering up to bufferSize values.
+//
+// The policy argument selects what happens when its buffer is full, it
+// defaults to [SlowConsumerBlock].
+//
+// Subscribing to a closed [Broadcaster] returns a closed [Subscription].
+func (b *Broadcaster[T]) Subscribe(bufferSize int, policy ...SlowConsumerPolicy) *Subscription[T] {
+	s := &Subscription[T]{b: b}
+	if len(policy) > 0 {
+		s.policy = policy[0]
+	}
+	var opts []ChanOption
+	if s.policy == SlowConsumerDrop {
+		opts = append(opts, WithBackpressure(DropNewest))
+	}
+	s.ch = MakeCloseSafeChanWith[T](bufferSize, opts...)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed.Load() {
+		_ = s.ch.Close()
+		return s
+	}
+	var subs []*Subscription[T]
+	if p := b.subs.Load(); p != nil {
+		subs = slices.Clone(*p)
+	}
+	subs = append(subs, s)
+	b.subs.Store(&subs)
+	return s
+}
+
+// Publish delivers value to every current subscriber, according to their
+// [SlowConsumerPolicy].
+//
+// It returns false if the [Broadcaster] is closed.
+func (b *Broadcaster[T]) Publish(value T) bool {
+	if b.closed.Load() {
+		return false
+	}
+	p := b.subs.Load()
+	if p == nil {
+		return true
+	}
+	for _, s := range *p {
+		switch s.policy {
+		case SlowConsumerDisconnect:
+			if !s.ch.TrySend(value) && !s.ch.IsClosed() {
+				s.unsubscribe(ErrSlowConsumer)
+			}
+		default:
+			// Blocks for SlowConsumerBlock, drops the value for
+			// SlowConsumerDrop. Either way, unsubscribing or closing the
+			// Broadcaster wakes us up.
+			_ = s.ch.Send(value)
+		}
+	}
+	return true
+}
+
+// Len returns the number of current subscribers.
+func (b *Broadcaster[T]) Len() int {
+	if p := b.subs.Load(); p != nil {
+		return len(*p)
+	}
+	return 0
+}
+
+// Close closes the [Broadcaster] and all of its subscriptions, which deliver
+// their buffered values before reporting themselves as closed.
+//
+// It is safe to call it from concurrently running goroutines, and never
+// returns an error.
+func (b *Broadcaster[T]) Close() error {
+	b.mu.Lock()
+	b.closed.Store(true)
+	p := b.subs.Swap(nil)
+	b.mu.Unlock()
+	if p != nil {
+		for _, s := range *p {
+			_ = s.ch.Close()
+		}
+	}
+	return nil
+}
+
+// remove drops s from the subscribers.
+func (b *Broadcaster[T]) remove(s *Subscription[T]) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p := b.subs.Load()
+	if p == nil {
+		return
+	}
+	i := slices.Index(*p, s)
+	if i < 0 {
+		return
+	}
+	subs := slices.Delete(slices.Clone(*p), i, i+1)
+	b.subs.Store(&subs)
+}
+
+// Unsubscribe removes the subscriber from the [Broadcaster] and closes the
+// [Subscription], which delivers its buffered values before reporting itself
+// as closed.
+//
+// It is safe to call it concurrently with [Broadcaster.Publish], and more than
+// once.
+func (s *Subscription[T]) Unsubscribe() {
+	s.unsubscribe(nil)
+}
+
+func (s *Subscription[T]) unsubscribe(cause error) {
+	// Closing first wakes up the publishers blocked on a full buffer.
+	_ = s.ch.CloseWithError(cause)
+	s.b.remove(s)
+}
+
+// Receive receives a value published to the subscriber, see
+// [CloseSafeChan.Receive].
+func (s *Subscription[T]) Receive() (T, bool) {
+	return s.ch.Receive()
+}
+
+// ReceiveContext receives a value published to the subscriber, see
+// [CloseSafeChan.ReceiveContext].
+func (s *Subscription[T]) ReceiveContext(ctx context.Context) (T, bool, error) {
+	return s.ch.ReceiveContext(ctx)
+}
+
+// Iter returns an iterator ranging on the values published to the subscriber,
+// see [CloseSafeChan.Iter].
+func (s *Subscription[T]) Iter() iter.Seq[T] {
+	return s.ch.Iter()
+}
+
+// IterContext is like [Subscription.Iter], but the iteration also stops once
+// ctx is done.
+func (s *Subscription[T]) IterContext(ctx context.Context) iter.Seq[T] {
+	return s.ch.IterContext(ctx)
+}
+
+// Done returns a channel that is closed when the [Subscription] starts
+// closing.
+func (s *Subscription[T]) Done() <-chan struct{} {
+	return s.ch.Done()
+}
+
+// Err returns [ErrSlowConsumer] if the [Subscription] was disconnected by the
+// [SlowConsumerDisconnect] policy, nil otherwise.
+func (s *Subscription[T]) Err() error {
+	return s.ch.Err()
+}
+
+// Dropped returns the number of values discarded by the [SlowConsumerDrop]
+// policy.
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.ch.Stats().Dropped
+}
+
+// Len returns the number of values buffered (unread) in the [Subscription].
+func (s *Subscription[T]) Len() int {
+	return s.ch.Len()
+}
+
+var (
+	_ io.Closer = &Broadcaster[any]{}
+)
@@ -0,0 +1,156 @@
+package atomic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestBroadcasterPublish(t *testing.T) {
+	var b Broadcaster[int]
+
+	subs := []*Subscription[int]{b.Subscribe(4), b.Subscribe(4), b.Subscribe(4)}
+	require.Equal(t, 3, b.Len())
+
+	for i := range 4 {
+		require.True(t, b.Publish(i))
+	}
+	require.NoError(t, b.Close())
+	require.False(t, b.Publish(4))
+	require.Zero(t, b.Len())
+
+	for _, s := range subs {
+		var got []int
+		for v := range s.Iter() {
+			got = append(got, v)
+		}
+		require.Equal(t, []int{0, 1, 2, 3}, got)
+		require.NoError(t, s.Err())
+	}
+}
+
+func TestBroadcasterSubscribeAfterClose(t *testing.T) {
+	var b Broadcaster[int]
+	require.NoError(t, b.Close())
+
+	s := b.Subscribe(1)
+	_, ok := s.Receive()
+	require.False(t, ok)
+	require.Zero(t, b.Len())
+}
+
+func TestBroadcasterUnsubscribe(t *testing.T) {
+	var b Broadcaster[int]
+
+	s1, s2 := b.Subscribe(1), b.Subscribe(2)
+	require.True(t, b.Publish(1))
+
+	s1.Unsubscribe()
+	s1.Unsubscribe()
+	require.Equal(t, 1, b.Len())
+
+	require.True(t, b.Publish(2))
+
+	v, ok := s1.Receive()
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+	_, ok = s1.Receive()
+	require.False(t, ok)
+
+	require.Equal(t, 2, s2.Len())
+}
+
+func TestBroadcasterSlowConsumerDrop(t *testing.T) {
+	var b Broadcaster[int]
+
+	s := b.Subscribe(2, SlowConsumerDrop)
+	for i := range 5 {
+		require.True(t, b.Publish(i))
+	}
+	require.Equal(t, uint64(3), s.Dropped())
+	require.Equal(t, 1, b.Len())
+}
+
+func TestBroadcasterSlowConsumerDisconnect(t *testing.T) {
+	var b Broadcaster[int]
+
+	slow := b.Subscribe(1, SlowConsumerDisconnect)
+	fast := b.Subscribe(8)
+
+	for i := range 3 {
+		require.True(t, b.Publish(i))
+	}
+	require.Equal(t, 1, b.Len())
+	require.Equal(t, 3, fast.Len())
+
+	<-slow.Done()
+	require.ErrorIs(t, slow.Err(), ErrSlowConsumer)
+	v, ok := slow.Receive()
+	require.True(t, ok)
+	require.Equal(t, 0, v)
+	_, ok = slow.Receive()
+	require.False(t, ok)
+}
+
+func TestBroadcasterUnsubscribeWakesBlockedPublisher(t *testing.T) {
+	var b Broadcaster[int]
+
+	s := b.Subscribe(0)
+
+	published := make(chan bool)
+	go func() { published <- b.Publish(1) }()
+
+	time.Sleep(10 * time.Millisecond)
+	s.Unsubscribe()
+
+	select {
+	case ok := <-published:
+		require.True(t, ok)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Publish stayed blocked on an unsubscribed subscriber")
+	}
+}
+
+func TestBroadcasterConcurrent(t *testing.T) {
+	const (
+		nbPublishers  = 8
+		nbSubscribers = 32
+		nbPublish     = 256
+	)
+
+	var b Broadcaster[int]
+	var eg errgroup.Group
+
+	start := make(chan struct{})
+	for i := range nbSubscribers {
+		eg.Go(func() error {
+			<-start
+			s := b.Subscribe(4)
+			for range s.Iter() {
+				if i%2 == 0 {
+					s.Unsubscribe()
+				}
+			}
+			return nil
+		})
+	}
+
+	var publishers errgroup.Group
+	for range nbPublishers {
+		publishers.Go(func() error {
+			<-start
+			for i := range nbPublish {
+				b.Publish(i)
+			}
+			return nil
+		})
+	}
+
+	close(start)
+	require.NoError(t, publishers.Wait())
+	require.NoError(t, b.Close())
+	require.NoError(t, eg.Wait())
+	require.Zero(t, b.Len())
+}
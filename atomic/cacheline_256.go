@@ -0,0 +1,6 @@
+//go:build s390x
+
+package atomic
+
+// CacheLineSize is the size of a CPU cache line on the target architecture.
+const CacheLineSize = 256
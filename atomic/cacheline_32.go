@@ -0,0 +1,6 @@
+//go:build arm || mips || mipsle || mips64 || mips64le
+
+package atomic
+
+// CacheLineSize is the size of a CPU cache line on the target architecture.
+const CacheLineSize = 32
@@ -0,0 +1,6 @@
+//go:build 386 || amd64 || loong64 || riscv64 || wasm
+
+package atomic
+
+// CacheLineSize is the size of a CPU cache line on the target architecture.
+const CacheLineSize = 64
@@ -0,0 +1,6 @@
+//go:build !(arm || mips || mipsle || mips64 || mips64le || 386 || amd64 || loong64 || riscv64 || wasm || arm64 || ppc64 || ppc64le || s390x)
+
+package atomic
+
+// CacheLineSize is the size of a CPU cache line on the target architecture.
+const CacheLineSize = 64
@@ -1,43 +1,84 @@
 package atomic
 
 import (
+	"context"
+	"errors"
 	"io"
 	"iter"
 	"sync/atomic"
+	"time"
 )
 
+// chanState is the state of a [CloseSafeChan].
+type chanState uint32
+
 const (
-	stateOpen uint32 = iota
+	stateOpen chanState = iota
 	stateClosing
 	stateClosed
 )
 
+// chanTransitions are the transitions of a [CloseSafeChan] : open, closing,
+// then closed.
+var chanTransitions = allowedTransitions(map[chanState][]chanState{
+	stateOpen:    {stateClosing},
+	stateClosing: {stateClosed},
+})
+
+var (
+	// ErrClosed is returned by operations on a channel that is closing or
+	// closed.
+	ErrClosed = errors.New("atomic: channel is closed")
+	// ErrTimeout is returned by operations giving up after their timeout.
+	ErrTimeout = errors.New("atomic: operation timed out")
+)
+
 // CloseSafeChan is a wrapper around a channel, bringing thread-safety on
 // concurrently executing send and close operations.
 //
 // Any goroutine can safely close a [CloseSafeChan] instance without worrying
 // if other goroutines are currently sending values.
 //
-// This is guaranteed by the implementation's inner-state, using atomic
-// compare-and-swap operations to transition from the channel open state to the
-// closing, and then closed state.
+// This is guaranteed by the implementation's inner-state, a [StateMachine]
+// transitioning from the channel open state to the closing, and then closed
+// state.
 //
 // During this transition, sending goroutines won't be able to send data on the
-// channel, and will be notified by [CloseSafeChan.Send] returning false.
+// channel, and will be notified by [CloseSafeChan.Send] returning false. This
+// includes goroutines blocked on a full buffer, which are woken up as soon as
+// the closing begins.
 type CloseSafeChan[T any] struct {
 	// ch is the underlying channel.
 	ch chan T
+	// done is closed when the closing begins, waking up blocked senders.
+	done chan struct{}
+	// err is the cause given to [CloseSafeChan.CloseWithError]. It is written
+	// by the closing goroutine before closing done, and only read after.
+	err error
+	// drained is signaled by the last sending goroutine leaving once the
+	// closing began, so that [CloseSafeChan.Close] can park instead of
+	// spinning while waiting for it.
+	drained chan struct{}
 	// state stores the current state of a [CloseSafeChan] instance :
 	// 	- 0 means it's currently open
 	// 	- 1 means it's closing (a goroutine has called [CloseSafeChan.Close])
 	// 	- 2 means it's closed ([CloseSafeChan.Close] is done)
-	state atomic.Uint32
+	state StateMachine[chanState]
 	// sending stores the number of goroutines that are currently trying to
 	// send data on the channel.
 	//
 	// It works as a gauge and will be incremented / decremented by each
 	// [CloseSafeChan.Send] call.
 	sending atomic.Int32
+	// backpressure is the policy applied by send operations when the buffer
+	// is full.
+	backpressure Backpressure
+	// dropped and rejected count the values discarded by the backpressure
+	// policy.
+	dropped, rejected atomic.Uint64
+	// debug tracks the blocked operations when enabled by [WithDebug], it is
+	// nil otherwise.
+	debug *chanDebug
 }
 
 // MakeCloseSafeChan initializes a new [CloseSafeChan] instance.
@@ -49,9 +90,27 @@ func MakeCloseSafeChan[T any](size ...int) *CloseSafeChan[T] {
 	if len(size) > 0 {
 		s = size[0]
 	}
-	return &CloseSafeChan[T]{
-		ch: make(chan T, s),
+	return MakeCloseSafeChanWith[T](s)
+}
+
+// MakeCloseSafeChanWith initializes a new [CloseSafeChan] instance with a
+// buffer of size elements, configured by opts.
+func MakeCloseSafeChanWith[T any](size int, opts ...ChanOption) *CloseSafeChan[T] {
+	var cfg chanConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	c := &CloseSafeChan[T]{
+		ch:           make(chan T, size),
+		done:         make(chan struct{}),
+		drained:      make(chan struct{}, 1),
+		backpressure: cfg.backpressure,
+	}
+	c.state.init(stateOpen, chanTransitions, nil)
+	if cfg.debug {
+		c.debug = newChanDebug()
 	}
+	return c
 }
 
 // Close closes the [CloseSafeChan] instance.
@@ -59,30 +118,56 @@ func MakeCloseSafeChan[T any](size ...int) *CloseSafeChan[T] {
 // It is safe to call it from concurrently running goroutines, as only the
 // first caller will be able to close the underlying channel.
 //
+// Goroutines blocked in a send operation are woken up and report the
+// [CloseSafeChan] instance as closed.
+//
 // It never returns an error.
 func (c *CloseSafeChan[T]) Close() error {
+	return c.CloseWithError(nil)
+}
+
+// CloseWithError closes the [CloseSafeChan] instance like
+// [CloseSafeChan.Close], recording err as the cause returned by
+// [CloseSafeChan.Err].
+//
+// Only the first call closing the [CloseSafeChan] instance records its cause,
+// and a nil err is equivalent to calling [CloseSafeChan.Close].
+//
+// It never returns an error.
+func (c *CloseSafeChan[T]) CloseWithError(err error) error {
 	// 1. Try to transition to a closing state (1).
 	// If it succeeds, it means that the calling goroutine is the first to call
 	// [CloseSafeChan.Close] and now owns the closing process (this is atomic
 	// of course). If it fails, it means another goroutine has called
 	// [CloseSafeChan.Close] beforehand, so we can simply let it handle the
 	// closing operation and return.
-	if !c.state.CompareAndSwap(stateOpen, stateClosing) {
+	if !c.state.Transition(stateOpen, stateClosing) {
 		return nil
 	}
-	// 2. Wait for all sending goroutines to finish their sending operations.
+	// 2. Record the cause and wake up the sending goroutines blocked on a full
+	// buffer, they will give up and report the [CloseSafeChan] as closed.
+	c.err = err
+	close(c.done)
+	// 3. Wait for all sending goroutines to finish their sending operations.
 	// Further operations won't be registered, as sending is allowed only if the
 	// state of the [CloseSafeChan] is open (0) and we previously transitioned
 	// to the closing state (1).
-	for c.sending.Load() != 0 {
+	// The last of them signals drained when leaving, the loop only guards
+	// against stale signals from senders that gave up right away.
+	if c.sending.Load() != 0 {
+		op := c.debug.enter(blockedClose)
+		for c.sending.Load() != 0 {
+			<-c.drained
+		}
+		c.debug.leave(op)
 	}
-	// 3. Try to transition to the closed state (2).
+	// 4. Try to transition to the closed state (2).
 	// If it succeeds, it means that the goroutine own the closing operation
 	// and can safely close the underlying channel without worrying of
 	// duplicate [close] calls. This is because we ensure that :
 	// 	- a single goroutine executed the transition to the closing state (1)
 	// 	- we drained all sending operations and won't register them any further
-	if c.state.CompareAndSwap(stateClosing, stateClosed) {
+	if c.state.Transition(stateClosing, stateClosed) {
 		close(c.ch)
 	}
 	return nil
@@ -93,14 +178,136 @@ func (c *CloseSafeChan[T]) Close() error {
 // It returns true if the [CloseSafeChan] instance is still open and the send
 // operation succeeded, false if the [CloseSafeChan] instance is closing or is
 // closed.
+//
+// If the buffer is full, the [Backpressure] policy of the [CloseSafeChan]
+// instance applies :
+//   - [Block] waits until there is room for the value or until the
+//     [CloseSafeChan] instance starts closing
+//   - [DropNewest] and [DropOldest] discard a value and return true
+//   - [ReturnError] returns false
 func (c *CloseSafeChan[T]) Send(value T) bool {
+	return c.send(value, nil, nil) == nil
+}
+
+// SendContext is like [CloseSafeChan.Send], but gives up when ctx is done.
+//
+// It returns nil if the value was sent, [ErrClosed] if the [CloseSafeChan]
+// instance is closing or closed, [ErrFull] if the [ReturnError] policy
+// rejected the value, and the context's error otherwise.
+func (c *CloseSafeChan[T]) SendContext(ctx context.Context, value T) error {
+	if err := c.send(value, ctx.Done(), nil); err != errCanceled {
+		return err
+	}
+	return ctx.Err()
+}
+
+// SendTimeout is like [CloseSafeChan.Send], but gives up after d.
+//
+// It returns nil if the value was sent, [ErrClosed] if the [CloseSafeChan]
+// instance is closing or closed, [ErrFull] if the [ReturnError] policy
+// rejected the value, and [ErrTimeout] otherwise.
+func (c *CloseSafeChan[T]) SendTimeout(value T, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	if err := c.send(value, nil, t.C); err != errCanceled {
+		return err
+	}
+	return ErrTimeout
+}
+
+// TrySend sends a value to the [CloseSafeChan] instance without blocking.
+//
+// It returns true if the value was sent, false if the buffer is full or if the
+// [CloseSafeChan] instance is closing or closed. The [Backpressure] policy
+// doesn't apply.
+func (c *CloseSafeChan[T]) TrySend(value T) bool {
 	c.sending.Add(1)
-	defer c.sending.Add(-1)
-	if c.state.Load() == stateOpen {
-		c.ch <- value
+	defer c.release()
+	if c.state.Current() != stateOpen {
+		return false
+	}
+	select {
+	case c.ch <- value:
 		return true
+	default:
+		return false
+	}
+}
+
+// release unregisters a sending goroutine, waking up the closing goroutine if
+// it is the last one it waits for.
+//
+// The closing goroutine transitions to the closing state before looking at the
+// gauge, so the goroutine bringing it to zero always sees that state.
+func (c *CloseSafeChan[T]) release() {
+	if c.sending.Add(-1) == 0 && c.state.Current() != stateOpen {
+		select {
+		case c.drained <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// errCanceled is returned by [CloseSafeChan.send] when it gives up.
+var errCanceled = errors.New("atomic: canceled")
+
+// send registers the calling goroutine as sending, and blocks until the value
+// is sent, the closing begins, or cancel or timeout fires (nil channels never
+// fire).
+func (c *CloseSafeChan[T]) send(value T, cancel <-chan struct{}, timeout <-chan time.Time) error {
+	c.sending.Add(1)
+	defer c.release()
+	if c.state.Current() != stateOpen {
+		return ErrClosed
+	}
+	select {
+	case c.ch <- value:
+		return nil
+	default:
+	}
+	switch c.backpressure {
+	case DropOldest:
+		if cap(c.ch) > 0 {
+			return c.sendDropOldest(value)
+		}
+		fallthrough
+	case DropNewest:
+		c.dropped.Add(1)
+		return nil
+	case ReturnError:
+		c.rejected.Add(1)
+		return ErrFull
+	}
+	defer c.debug.leave(c.debug.enter(blockedSend))
+	select {
+	case c.ch <- value:
+		return nil
+	case <-c.done:
+		return ErrClosed
+	case <-cancel:
+		return errCanceled
+	case <-timeout:
+		return errCanceled
+	}
+}
+
+// sendDropOldest evicts buffered values until value fits in the buffer.
+//
+// The calling goroutine must be registered as sending, which guarantees that
+// the underlying channel isn't closed.
+func (c *CloseSafeChan[T]) sendDropOldest(value T) error {
+	for {
+		select {
+		case <-c.ch:
+			c.dropped.Add(1)
+		default:
+		}
+		select {
+		case c.ch <- value:
+			return nil
+		default:
+		}
 	}
-	return false
 }
 
 // Receive receives a value from the [CloseSafeChan] instance.
@@ -113,10 +320,72 @@ func (c *CloseSafeChan[T]) Send(value T) bool {
 //	ch := make(chan struct{})
 //	v, ok := <-ch
 func (c *CloseSafeChan[T]) Receive() (T, bool) {
+	if c.debug != nil {
+		value, ok, _ := c.receive(nil, nil)
+		return value, ok
+	}
 	value, ok := <-c.ch
 	return value, ok
 }
 
+// ReceiveContext is like [CloseSafeChan.Receive], but gives up when ctx is
+// done, returning the zero value, false and the context's error.
+func (c *CloseSafeChan[T]) ReceiveContext(ctx context.Context) (T, bool, error) {
+	value, ok, err := c.receive(ctx.Done(), nil)
+	if err != nil {
+		return value, ok, ctx.Err()
+	}
+	return value, ok, nil
+}
+
+// ReceiveTimeout is like [CloseSafeChan.Receive], but gives up after d,
+// returning the zero value, false and [ErrTimeout].
+func (c *CloseSafeChan[T]) ReceiveTimeout(d time.Duration) (T, bool, error) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	value, ok, err := c.receive(nil, t.C)
+	if err != nil {
+		return value, ok, ErrTimeout
+	}
+	return value, ok, nil
+}
+
+// TryReceive receives a value from the [CloseSafeChan] instance without
+// blocking.
+//
+// The ok result reports whether a value was received, and the open result
+// whether the [CloseSafeChan] instance may still deliver values : it is false
+// once the [CloseSafeChan] instance is closed and its buffer is drained.
+func (c *CloseSafeChan[T]) TryReceive() (value T, ok, open bool) {
+	select {
+	case value, ok = <-c.ch:
+		return value, ok, ok
+	default:
+		return value, false, true
+	}
+}
+
+// receive blocks until a value is received, the underlying channel is closed,
+// or cancel or timeout fires (nil channels never fire).
+//
+// Buffered values are always received before giving up.
+func (c *CloseSafeChan[T]) receive(cancel <-chan struct{}, timeout <-chan time.Time) (T, bool, error) {
+	select {
+	case value, ok := <-c.ch:
+		return value, ok, nil
+	default:
+	}
+	defer c.debug.leave(c.debug.enter(blockedReceive))
+	select {
+	case value, ok := <-c.ch:
+		return value, ok, nil
+	case <-cancel:
+		return *new(T), false, errCanceled
+	case <-timeout:
+		return *new(T), false, errCanceled
+	}
+}
+
 // Iter returns an iterator ranging on all the values buffered or sent in the
 // underlying channel.
 //
@@ -126,6 +395,9 @@ func (c *CloseSafeChan[T]) Receive() (T, bool) {
 //   - You can skip values using the continue statement
 //   - You can break out of the iteration using the break statement
 func (c *CloseSafeChan[T]) Iter() iter.Seq[T] {
+	if c.debug != nil {
+		return c.IterContext(context.Background())
+	}
 	return func(yield func(T) bool) {
 		for value := range c.ch {
 			if !yield(value) {
@@ -135,6 +407,55 @@ func (c *CloseSafeChan[T]) Iter() iter.Seq[T] {
 	}
 }
 
+// IterContext is like [CloseSafeChan.Iter], but the iteration also stops
+// once ctx is done.
+func (c *CloseSafeChan[T]) IterContext(ctx context.Context) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			value, ok, err := c.receive(ctx.Done(), nil)
+			if err != nil || !ok || !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// Done returns a channel that is closed when the [CloseSafeChan] instance
+// starts closing.
+//
+// Values may still be buffered at that point, receivers drain them before
+// observing the [CloseSafeChan] instance as closed.
+func (c *CloseSafeChan[T]) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the cause given to [CloseSafeChan.CloseWithError].
+//
+// It returns nil while the [CloseSafeChan] instance is open, or if it was
+// closed by [CloseSafeChan.Close].
+func (c *CloseSafeChan[T]) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// IsClosed reports whether the [CloseSafeChan] instance is closing or closed,
+// that is if send operations are rejected.
+func (c *CloseSafeChan[T]) IsClosed() bool {
+	return c.state.Current() != stateOpen
+}
+
+// Stats returns the counters of the [CloseSafeChan] instance.
+func (c *CloseSafeChan[T]) Stats() ChanStats {
+	return ChanStats{
+		Dropped:  c.dropped.Load(),
+		Rejected: c.rejected.Load(),
+	}
+}
+
 // Len returns the number of elements queued (unread) in the [CloseSafeChan]
 // instance's buffer.
 //
@@ -1,6 +1,11 @@
 package atomic
 
 import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -73,7 +78,7 @@ func TestCloseSafeChanClose(t *testing.T) {
 	require.False(t, ok)
 
 	require.Zero(t, ch.sending.Load())
-	require.Equal(t, uint32(2), ch.state.Load())
+	require.Equal(t, stateClosed, ch.state.Current())
 }
 
 func TestCloseSafeChanConcurrentSendsReceivesCloses(t *testing.T) {
@@ -212,6 +217,291 @@ func TestCloseSafeChanSendIterBuffered(t *testing.T) {
 
 	require.Equal(t, len(data), received)
 }
+
+func TestCloseSafeChanTrySend(t *testing.T) {
+	ch := MakeCloseSafeChan[int](1)
+
+	require.True(t, ch.TrySend(1))
+	require.False(t, ch.TrySend(2))
+
+	v, ok := ch.Receive()
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+
+	require.NoError(t, ch.Close())
+	require.False(t, ch.TrySend(3))
+}
+
+func TestCloseSafeChanSendContext(t *testing.T) {
+	ch := MakeCloseSafeChan[int](1)
+
+	require.NoError(t, ch.SendContext(context.Background(), 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, ch.SendContext(ctx, 2), context.DeadlineExceeded)
+
+	require.NoError(t, ch.Close())
+	require.ErrorIs(t, ch.SendContext(context.Background(), 3), ErrClosed)
+}
+
+func TestCloseSafeChanSendTimeout(t *testing.T) {
+	ch := MakeCloseSafeChan[int](1)
+
+	require.NoError(t, ch.SendTimeout(1, time.Second))
+	require.ErrorIs(t, ch.SendTimeout(2, 10*time.Millisecond), ErrTimeout)
+
+	require.NoError(t, ch.Close())
+	require.ErrorIs(t, ch.SendTimeout(3, time.Second), ErrClosed)
+}
+
+func TestCloseSafeChanCloseWakesBlockedSenders(t *testing.T) {
+	const nbSenders = 16
+
+	ch := MakeCloseSafeChan[int]()
+
+	var eg errgroup.Group
+	var closed atomic.Uint32
+	for i := range nbSenders {
+		eg.Go(func() error {
+			var err error
+			if i%2 == 0 {
+				err = ch.SendContext(context.Background(), i)
+			} else if !ch.Send(i) {
+				err = ErrClosed
+			}
+			if errors.Is(err, ErrClosed) {
+				closed.Add(1)
+			}
+			return nil
+		})
+	}
+
+	require.Eventually(t, func() bool {
+		return ch.sending.Load() == nbSenders
+	}, time.Second, time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		require.NoError(t, ch.Close())
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not return while senders were blocked")
+	}
+
+	require.NoError(t, eg.Wait())
+	require.Equal(t, uint32(nbSenders), closed.Load())
+}
+
+func TestCloseSafeChanTryReceive(t *testing.T) {
+	ch := MakeCloseSafeChan[int](1)
+
+	_, ok, open := ch.TryReceive()
+	require.False(t, ok)
+	require.True(t, open)
+
+	require.True(t, ch.Send(1))
+	require.NoError(t, ch.Close())
+
+	v, ok, open := ch.TryReceive()
+	require.True(t, ok)
+	require.True(t, open)
+	require.Equal(t, 1, v)
+
+	_, ok, open = ch.TryReceive()
+	require.False(t, ok)
+	require.False(t, open)
+}
+
+func TestCloseSafeChanReceiveContext(t *testing.T) {
+	ch := MakeCloseSafeChan[int](1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, ok, err := ch.ReceiveContext(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	require.False(t, ok)
+
+	// Buffered values win over a done context.
+	require.True(t, ch.Send(1))
+	v, ok, err := ch.ReceiveContext(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+
+	require.NoError(t, ch.Close())
+	_, ok, err = ch.ReceiveContext(context.Background())
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestCloseSafeChanReceiveTimeout(t *testing.T) {
+	ch := MakeCloseSafeChan[int]()
+
+	_, ok, err := ch.ReceiveTimeout(10 * time.Millisecond)
+	require.ErrorIs(t, err, ErrTimeout)
+	require.False(t, ok)
+
+	go func() { _ = ch.Send(1) }()
+	v, ok, err := ch.ReceiveTimeout(5 * time.Second)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+}
+
+func TestCloseSafeChanIterContext(t *testing.T) {
+	ch := MakeCloseSafeChan[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var eg errgroup.Group
+	var received atomic.Uint32
+	eg.Go(func() error {
+		for range ch.IterContext(ctx) {
+			received.Add(1)
+		}
+		return nil
+	})
+
+	for i := range 4 {
+		require.True(t, ch.Send(i))
+	}
+	cancel()
+
+	require.NoError(t, eg.Wait())
+	require.Equal(t, uint32(4), received.Load())
+	require.NoError(t, ch.Close())
+}
+
+func TestCloseSafeChanCloseWithError(t *testing.T) {
+	errAbort := errors.New("abort")
+
+	ch := MakeCloseSafeChan[int](1)
+	require.NoError(t, ch.Err())
+	require.False(t, ch.IsClosed())
+
+	select {
+	case <-ch.Done():
+		t.Fatal("Done fired on an open channel")
+	default:
+	}
+
+	require.True(t, ch.Send(1))
+	require.NoError(t, ch.CloseWithError(errAbort))
+	require.NoError(t, ch.CloseWithError(errors.New("ignored")))
+	require.NoError(t, ch.Close())
+
+	<-ch.Done()
+	require.True(t, ch.IsClosed())
+	require.ErrorIs(t, ch.Err(), errAbort)
+
+	v, ok := ch.Receive()
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+
+	_, ok = ch.Receive()
+	require.False(t, ok)
+}
+
+func TestCloseSafeChanCloseErrNil(t *testing.T) {
+	ch := MakeCloseSafeChan[int]()
+	require.NoError(t, ch.Close())
+
+	<-ch.Done()
+	require.True(t, ch.IsClosed())
+	require.NoError(t, ch.Err())
+}
+
+func TestCloseSafeChanCloseParks(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+
+	ch := MakeCloseSafeChan[struct{}]()
+
+	// Simulating a goroutine in the middle of a send operation.
+	ch.sending.Add(1)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		require.NoError(t, ch.Close())
+	}()
+
+	// The closing goroutine must be parked on a channel receive rather than
+	// running (spinning) while the sender is in-flight.
+	require.Eventually(t, func() bool {
+		buf := make([]byte, 1<<20)
+		buf = buf[:runtime.Stack(buf, true)]
+		for _, g := range strings.Split(string(buf), "\n\n") {
+			if strings.Contains(g, "[chan receive]") && strings.Contains(g, ").Close(") {
+				return true
+			}
+		}
+		return false
+	}, time.Second, time.Millisecond)
+
+	ch.release()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not return after the last sender left")
+	}
+	require.Equal(t, stateClosed, ch.state.Current())
+}
+
+func TestCloseSafeChanConcurrentSendsClosesSingleProc(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+
+	const (
+		nbRounds   = 200
+		nbSenders  = 32
+		nbClosers  = 8
+		nbSend     = 64
+		bufferSize = 4
+	)
+
+	deadline := time.After(30 * time.Second)
+	for range nbRounds {
+		ch := MakeCloseSafeChan[int](bufferSize)
+
+		var eg errgroup.Group
+		eg.Go(func() error {
+			for range ch.Iter() {
+			}
+			return nil
+		})
+		for range nbSenders {
+			eg.Go(func() error {
+				for i := range nbSend {
+					if !ch.Send(i) {
+						return nil
+					}
+				}
+				return nil
+			})
+		}
+		for range nbClosers {
+			eg.Go(func() error {
+				runtime.Gosched()
+				return ch.Close()
+			})
+		}
+
+		done := make(chan error, 1)
+		go func() { done <- eg.Wait() }()
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-deadline:
+			t.Fatal("senders and closers did not complete at GOMAXPROCS=1")
+		}
+	}
+}
+
 func BenchmarkChanSendReceiveSeq(b *testing.B) {
 	b.ReportAllocs()
 
@@ -287,3 +577,36 @@ func BenchmarkChanCloseSafeSendReceivePar(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkChanCloseSafeClose(b *testing.B) {
+	b.ReportAllocs()
+
+	for range b.N {
+		ch := MakeCloseSafeChan[struct{}](1)
+		_ = ch.Send(struct{}{})
+		_ = ch.Close()
+	}
+}
+
+func BenchmarkChanCloseSafeCloseWithSenders(b *testing.B) {
+	const nbSenders = 8
+
+	b.ReportAllocs()
+
+	for range b.N {
+		ch := MakeCloseSafeChan[struct{}]()
+
+		var wg sync.WaitGroup
+		for range nbSenders {
+			wg.Go(func() {
+				for ch.Send(struct{}{}) {
+				}
+			})
+		}
+		for range nbSenders {
+			_, _ = ch.Receive()
+		}
+		_ = ch.Close()
+		wg.Wait()
+	}
+}
@@ -0,0 +1,100 @@
+package atomic
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a sum of int64 values, spreading concurrent additions across
+// cells on distinct cache lines so that goroutines running on different
+// processors don't contend on a single word.
+//
+// Additions are cheap, at the expense of reading the sum, which visits all
+// the cells. It suits counters written much more often than read, such as
+// statistics.
+//
+// The zero value is ready to use and holds 0. A [Counter] must not be copied
+// after first use.
+type Counter struct {
+	once  sync.Once
+	cells []counterCell
+	// tokens holds *uint32 tokens selecting a cell. As a [sync.Pool] keeps a
+	// cache per processor, goroutines running on the same processor mostly
+	// get the same token.
+	tokens sync.Pool
+	next   atomic.Uint32
+}
+
+type counterCell struct {
+	v atomic.Int64
+	_ [CacheLineSize - 8]byte
+}
+
+// init allocates a cell per processor, rounded up to a power of 2.
+func (c *Counter) init() {
+	n := 1
+	for n < runtime.GOMAXPROCS(0) {
+		n *= 2
+	}
+	c.cells = make([]counterCell, n)
+}
+
+// Add adds delta to the counter, in the cell of the current processor.
+func (c *Counter) Add(delta int64) {
+	c.once.Do(c.init)
+	token, _ := c.tokens.Get().(*uint32)
+	if token == nil {
+		token = new(uint32)
+		*token = c.next.Add(1)
+	}
+	c.cells[*token&uint32(len(c.cells)-1)].v.Add(delta)
+	c.tokens.Put(token)
+}
+
+// AddHint adds delta to the counter, in the cell selected by hint. Goroutines
+// giving distinct hints, such as worker indexes, don't contend unless there are
+// more of them than cells.
+func (c *Counter) AddHint(hint int, delta int64) {
+	c.once.Do(c.init)
+	c.cells[uint(hint)&uint(len(c.cells)-1)].v.Add(delta)
+}
+
+// Inc adds 1 to the counter.
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Sum returns the sum of the values added to the counter.
+//
+// It isn't an atomic snapshot : additions running concurrently may or may not
+// be accounted for.
+func (c *Counter) Sum() int64 {
+	c.once.Do(c.init)
+	var sum int64
+	for i := range c.cells {
+		sum += c.cells[i].v.Load()
+	}
+	return sum
+}
+
+// SumAndReset returns the sum of the values added to the counter, resetting
+// it to 0.
+//
+// Like [Counter.Sum], it isn't an atomic snapshot, but each addition is
+// accounted for exactly once, either by this call or by the next ones.
+func (c *Counter) SumAndReset() int64 {
+	c.once.Do(c.init)
+	var sum int64
+	for i := range c.cells {
+		sum += c.cells[i].v.Swap(0)
+	}
+	return sum
+}
+
+// Load returns the sum of the values added to the counter, like
+// [Counter.Sum], so that a [Counter] is a [Loader] like the [Integer]
+// implementations.
+func (c *Counter) Load() int64 {
+	return c.Sum()
+}
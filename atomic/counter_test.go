@@ -0,0 +1,108 @@
+package atomic
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounter(t *testing.T) {
+	var c Counter
+	require.Zero(t, c.Sum())
+	c.Inc()
+	c.Add(41)
+	c.AddHint(3, -2)
+	c.AddHint(-1, 2)
+	require.Equal(t, int64(42), c.Sum())
+	require.Equal(t, int64(42), c.Load())
+	require.Equal(t, int64(42), c.SumAndReset())
+	require.Zero(t, c.Sum())
+
+	var l Loader[int64] = &c
+	c.Add(7)
+	require.Equal(t, int64(7), l.Load())
+}
+
+func TestCounterCellsPadded(t *testing.T) {
+	require.Equal(t, uintptr(CacheLineSize), unsafe.Sizeof(counterCell{}))
+	var c Counter
+	c.Inc()
+	require.GreaterOrEqual(t, len(c.cells), 1)
+	require.Zero(t, len(c.cells)&(len(c.cells)-1))
+}
+
+func TestCounterConcurrent(t *testing.T) {
+	const (
+		goroutines = 16
+		adds       = 1000
+	)
+	var (
+		wg    sync.WaitGroup
+		c     Counter
+		reset atomic.Int64
+	)
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range adds {
+				if g%2 == 0 {
+					c.Inc()
+				} else {
+					c.AddHint(g, 1)
+				}
+				if g == 0 && i%100 == 0 {
+					reset.Add(c.SumAndReset())
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int64(goroutines*adds), reset.Load()+c.Sum())
+}
+
+func BenchmarkCounter(b *testing.B) {
+	// Running 4 goroutines per processor.
+	b.Run("sync/atomic.Int64", func(b *testing.B) {
+		var v atomic.Int64
+		b.SetParallelism(4)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				v.Add(1)
+			}
+		})
+	})
+	b.Run("Counter.Inc", func(b *testing.B) {
+		var c Counter
+		b.SetParallelism(4)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				c.Inc()
+			}
+		})
+	})
+	b.Run("Counter.AddHint", func(b *testing.B) {
+		var (
+			c    Counter
+			next atomic.Int64
+		)
+		b.SetParallelism(4)
+		b.RunParallel(func(pb *testing.PB) {
+			hint := int(next.Add(1))
+			for pb.Next() {
+				c.AddHint(hint, 1)
+			}
+		})
+	})
+}
+
+func BenchmarkCounterSum(b *testing.B) {
+	var c Counter
+	c.Inc()
+	for b.Loop() {
+		c.Sum()
+	}
+}
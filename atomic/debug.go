@@ -0,0 +1,125 @@
+package atomic
+
+import (
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// BlockedProfile is the name of the [pprof.Profile] recording the stacks of
+// the goroutines currently blocked in an operation on a [CloseSafeChan] created
+// with [WithDebug].
+//
+// It is registered when the first such [CloseSafeChan] is created, and can be
+// fetched like any other profile, for instance from net/http/pprof :
+//
+//	go tool pprof http://localhost:6060/debug/pprof/github.com/wazazaby/gs/atomic.CloseSafeChan.blocked
+const BlockedProfile = "github.com/wazazaby/gs/atomic.CloseSafeChan.blocked"
+
+var (
+	blockedProfileOnce sync.Once
+	blockedProfile     *pprof.Profile
+)
+
+// WithDebug enables the blocking diagnostics of the [CloseSafeChan] :
+// goroutines blocked in send, receive and close operations are recorded in the
+// [BlockedProfile] profile and counted by [CloseSafeChan.Blocked].
+//
+// Operations that don't block aren't recorded, but all operations pay for an
+// extra check.
+func WithDebug() ChanOption {
+	return func(c *chanConfig) { c.debug = true }
+}
+
+// BlockedStats holds the gauges of the goroutines blocked on a [CloseSafeChan],
+// see [CloseSafeChan.Blocked].
+type BlockedStats struct {
+	// Senders, Receivers and Closers are the number of goroutines currently
+	// blocked in a send, receive or close operation.
+	Senders, Receivers, Closers int
+	// OldestWait is how long the goroutine blocked for the longest time has
+	// been waiting, 0 if there are none.
+	OldestWait time.Duration
+}
+
+// Blocked returns the gauges of the goroutines currently blocked on the
+// [CloseSafeChan] instance.
+//
+// They are only maintained with [WithDebug], and are always zero otherwise.
+func (c *CloseSafeChan[T]) Blocked() BlockedStats {
+	return c.debug.stats()
+}
+
+type blockedKind uint8
+
+const (
+	blockedSend blockedKind = iota
+	blockedReceive
+	blockedClose
+)
+
+// blockedOp is a blocked operation, its address is its key in the profile.
+type blockedOp struct {
+	kind  blockedKind
+	since time.Time
+}
+
+// chanDebug tracks the blocked operations of a [CloseSafeChan], a nil
+// *chanDebug tracks nothing.
+type chanDebug struct {
+	mu      sync.Mutex
+	blocked map[*blockedOp]struct{}
+}
+
+func newChanDebug() *chanDebug {
+	blockedProfileOnce.Do(func() {
+		blockedProfile = pprof.NewProfile(BlockedProfile)
+	})
+	return &chanDebug{blocked: make(map[*blockedOp]struct{})}
+}
+
+// enter records the calling operation as blocked, its stack starting at the
+// caller of enter. The returned operation must be given to leave once done.
+func (d *chanDebug) enter(kind blockedKind) *blockedOp {
+	if d == nil {
+		return nil
+	}
+	op := &blockedOp{kind: kind, since: time.Now()}
+	d.mu.Lock()
+	d.blocked[op] = struct{}{}
+	d.mu.Unlock()
+	blockedProfile.Add(op, 1)
+	return op
+}
+
+func (d *chanDebug) leave(op *blockedOp) {
+	if d == nil {
+		return
+	}
+	blockedProfile.Remove(op)
+	d.mu.Lock()
+	delete(d.blocked, op)
+	d.mu.Unlock()
+}
+
+func (d *chanDebug) stats() BlockedStats {
+	var s BlockedStats
+	if d == nil {
+		return s
+	}
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for op := range d.blocked {
+		switch op.kind {
+		case blockedSend:
+			s.Senders++
+		case blockedReceive:
+			s.Receivers++
+		case blockedClose:
+			s.Closers++
+		}
+		s.OldestWait = max(s.OldestWait, now.Sub(op.since))
+	}
+	return s
+}
@@ -0,0 +1,131 @@
+package atomic
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseSafeChanDebugDisabled(t *testing.T) {
+	ch := MakeCloseSafeChan[int]()
+	go ch.Send(1)
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, BlockedStats{}, ch.Blocked())
+	require.NoError(t, ch.Close())
+}
+
+func TestCloseSafeChanDebugSenders(t *testing.T) {
+	ch := MakeCloseSafeChanWith[int](1, WithDebug())
+	require.True(t, ch.Send(0))
+	require.Equal(t, BlockedStats{}, ch.Blocked())
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ch.Send(1)
+		}()
+	}
+	require.Eventually(t, func() bool {
+		return ch.Blocked().Senders == 3
+	}, time.Second, time.Millisecond)
+
+	time.Sleep(10 * time.Millisecond)
+	s := ch.Blocked()
+	require.Zero(t, s.Receivers)
+	require.Zero(t, s.Closers)
+	require.GreaterOrEqual(t, s.OldestWait, 10*time.Millisecond)
+
+	p := pprof.Lookup(BlockedProfile)
+	require.NotNil(t, p)
+	require.GreaterOrEqual(t, p.Count(), 3)
+	var buf bytes.Buffer
+	require.NoError(t, p.WriteTo(&buf, 1))
+	require.Contains(t, buf.String(), "atomic.(*CloseSafeChan[...]).send")
+	require.Contains(t, buf.String(), "TestCloseSafeChanDebugSenders")
+
+	require.NoError(t, ch.Close())
+	wg.Wait()
+	require.Equal(t, BlockedStats{}, ch.Blocked())
+}
+
+func TestCloseSafeChanDebugReceivers(t *testing.T) {
+	ch := MakeCloseSafeChanWith[int](0, WithDebug())
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		ch.Receive()
+	}()
+	go func() {
+		defer wg.Done()
+		for range ch.Iter() {
+		}
+	}()
+	require.Eventually(t, func() bool {
+		return ch.Blocked().Receivers == 2
+	}, time.Second, time.Millisecond)
+	require.Zero(t, ch.Blocked().Senders)
+
+	require.NoError(t, ch.Close())
+	wg.Wait()
+	require.Equal(t, BlockedStats{}, ch.Blocked())
+}
+
+func TestCloseSafeChanDebugCloser(t *testing.T) {
+	ch := MakeCloseSafeChanWith[int](0, WithDebug())
+	// A registered sender, delaying the closing until it leaves.
+	ch.sending.Add(1)
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		ch.Close()
+	}()
+	require.Eventually(t, func() bool {
+		return ch.Blocked().Closers == 1
+	}, time.Second, time.Millisecond)
+
+	ch.release()
+	<-closed
+	require.Equal(t, BlockedStats{}, ch.Blocked())
+}
+
+func TestCloseSafeChanDebugSelect(t *testing.T) {
+	full := MakeCloseSafeChanWith[int](1, WithDebug())
+	empty := MakeCloseSafeChanWith[int](0, WithDebug())
+	require.True(t, full.Send(0))
+
+	// Cases that can proceed right away aren't recorded.
+	_, err := Select(context.Background(), SelectRecv(full))
+	require.NoError(t, err)
+	require.True(t, full.Send(0))
+	require.Equal(t, BlockedStats{}, full.Blocked())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Select(context.Background(), SelectSend(full, 1), SelectRecv(empty))
+	}()
+	require.Eventually(t, func() bool {
+		return full.Blocked().Senders == 1 && empty.Blocked().Receivers == 1
+	}, time.Second, time.Millisecond)
+
+	var buf bytes.Buffer
+	require.NoError(t, pprof.Lookup(BlockedProfile).WriteTo(&buf, 1))
+	require.Contains(t, buf.String(), "atomic.Select")
+
+	require.True(t, empty.Send(1))
+	<-done
+	require.Equal(t, BlockedStats{}, full.Blocked())
+	require.Equal(t, BlockedStats{}, empty.Blocked())
+	require.NoError(t, full.Close())
+	require.NoError(t, empty.Close())
+}
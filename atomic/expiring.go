@@ -0,0 +1,208 @@
+package atomic
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// ExpiringChan is a [CloseSafeChan] whose values expire : each value is stamped
+// when sent, and values that waited longer than the maximum age are discarded
+// by the receive operations instead of being delivered.
+//
+// Expired values are counted (see [ExpiringChan.Expired]) and reported to the
+// hook set by [WithExpireHook].
+type ExpiringChan[T any] struct {
+	ch       *CloseSafeChan[stamped[T]]
+	maxAge   time.Duration
+	now      func() time.Time
+	onExpire func(value T, age time.Duration)
+	expired  atomic.Uint64
+}
+
+type stamped[T any] struct {
+	value  T
+	sentAt time.Time
+}
+
+// ExpiringChanOption configures an [ExpiringChan], see [MakeExpiringChan].
+type ExpiringChanOption func(*expiringChanConfig)
+
+type expiringChanConfig struct {
+	now func() time.Time
+	// onExpire is a func(T, time.Duration), T being checked by
+	// [MakeExpiringChan].
+	onExpire any
+}
+
+// WithClock sets the clock stamping and aging the values of an
+// [ExpiringChan]. It defaults to [time.Now], and is meant for tests.
+func WithClock(now func() time.Time) ExpiringChanOption {
+	return func(c *expiringChanConfig) { c.now = now }
+}
+
+// WithExpireHook sets a function called by the receiving goroutine with each
+// value discarded by an [ExpiringChan], and the time it waited.
+//
+// T must be the element type of the [ExpiringChan], [MakeExpiringChan] panics
+// otherwise.
+func WithExpireHook[T any](fn func(value T, age time.Duration)) ExpiringChanOption {
+	return func(c *expiringChanConfig) { c.onExpire = fn }
+}
+
+// MakeExpiringChan initializes a new [ExpiringChan] instance with a buffer of
+// size values, discarding the values older than maxAge.
+func MakeExpiringChan[T any](size int, maxAge time.Duration, opts ...ExpiringChanOption) *ExpiringChan[T] {
+	cfg := expiringChanConfig{now: time.Now}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	var onExpire func(T, time.Duration)
+	if cfg.onExpire != nil {
+		var ok bool
+		if onExpire, ok = cfg.onExpire.(func(T, time.Duration)); !ok {
+			panic(fmt.Sprintf("atomic: expire hook %T of an ExpiringChan[%s]", cfg.onExpire, reflect.TypeFor[T]()))
+		}
+	}
+	return &ExpiringChan[T]{
+		ch:       MakeCloseSafeChan[stamped[T]](size),
+		maxAge:   maxAge,
+		now:      cfg.now,
+		onExpire: onExpire,
+	}
+}
+
+// Close closes the [ExpiringChan] instance, see [CloseSafeChan.Close].
+func (c *ExpiringChan[T]) Close() error {
+	return c.ch.Close()
+}
+
+// CloseWithError closes the [ExpiringChan] instance, see
+// [CloseSafeChan.CloseWithError].
+func (c *ExpiringChan[T]) CloseWithError(err error) error {
+	return c.ch.CloseWithError(err)
+}
+
+// Send stamps and sends a value, see [CloseSafeChan.Send].
+func (c *ExpiringChan[T]) Send(value T) bool {
+	return c.ch.Send(c.stamp(value))
+}
+
+// SendContext stamps and sends a value, see [CloseSafeChan.SendContext].
+//
+// The value is stamped before waiting for room in the buffer.
+func (c *ExpiringChan[T]) SendContext(ctx context.Context, value T) error {
+	return c.ch.SendContext(ctx, c.stamp(value))
+}
+
+// TrySend stamps and sends a value without blocking, see
+// [CloseSafeChan.TrySend].
+func (c *ExpiringChan[T]) TrySend(value T) bool {
+	return c.ch.TrySend(c.stamp(value))
+}
+
+func (c *ExpiringChan[T]) stamp(value T) stamped[T] {
+	return stamped[T]{value: value, sentAt: c.now()}
+}
+
+// Receive receives the next value that didn't expire, see
+// [CloseSafeChan.Receive].
+func (c *ExpiringChan[T]) Receive() (T, bool) {
+	for {
+		s, ok := c.ch.Receive()
+		if !ok {
+			return s.value, false
+		}
+		if c.fresh(s) {
+			return s.value, true
+		}
+	}
+}
+
+// ReceiveContext receives the next value that didn't expire, see
+// [CloseSafeChan.ReceiveContext].
+func (c *ExpiringChan[T]) ReceiveContext(ctx context.Context) (T, bool, error) {
+	for {
+		s, ok, err := c.ch.ReceiveContext(ctx)
+		if err != nil || !ok {
+			return s.value, ok, err
+		}
+		if c.fresh(s) {
+			return s.value, true, nil
+		}
+	}
+}
+
+// fresh reports whether s didn't expire, counting and reporting it otherwise.
+func (c *ExpiringChan[T]) fresh(s stamped[T]) bool {
+	age := c.now().Sub(s.sentAt)
+	if age <= c.maxAge {
+		return true
+	}
+	c.expired.Add(1)
+	if c.onExpire != nil {
+		c.onExpire(s.value, age)
+	}
+	return false
+}
+
+// Iter returns an iterator ranging on the values that didn't expire, see
+// [CloseSafeChan.Iter].
+func (c *ExpiringChan[T]) Iter() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for s := range c.ch.Iter() {
+			if c.fresh(s) && !yield(s.value) {
+				return
+			}
+		}
+	}
+}
+
+// IterContext is like [ExpiringChan.Iter], but the iteration also stops once
+// ctx is done.
+func (c *ExpiringChan[T]) IterContext(ctx context.Context) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for s := range c.ch.IterContext(ctx) {
+			if c.fresh(s) && !yield(s.value) {
+				return
+			}
+		}
+	}
+}
+
+// Expired returns the number of values discarded because they expired.
+func (c *ExpiringChan[T]) Expired() uint64 {
+	return c.expired.Load()
+}
+
+// Done returns a channel that is closed when the [ExpiringChan] instance
+// starts closing, see [CloseSafeChan.Done].
+func (c *ExpiringChan[T]) Done() <-chan struct{} {
+	return c.ch.Done()
+}
+
+// Err returns the cause given to [ExpiringChan.CloseWithError].
+func (c *ExpiringChan[T]) Err() error {
+	return c.ch.Err()
+}
+
+// Len returns the number of values queued in the buffer, including the ones
+// that expired but weren't discarded yet.
+func (c *ExpiringChan[T]) Len() int {
+	return c.ch.Len()
+}
+
+// Cap returns the buffer capacity of the [ExpiringChan] instance.
+func (c *ExpiringChan[T]) Cap() int {
+	return c.ch.Cap()
+}
+
+var (
+	_ io.Closer     = &ExpiringChan[any]{}
+	_ Sender[any]   = &ExpiringChan[any]{}
+	_ Receiver[any] = &ExpiringChan[any]{}
+)
@@ -0,0 +1,94 @@
+package atomic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func TestExpiringChan(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+
+	var expired []string
+	ch := MakeExpiringChan[string](8, time.Second,
+		WithClock(clock.Now),
+		WithExpireHook(func(v string, age time.Duration) {
+			expired = append(expired, v)
+			require.Greater(t, age, time.Second)
+		}),
+	)
+
+	require.True(t, ch.Send("old-1"))
+	require.True(t, ch.Send("old-2"))
+	clock.Advance(800 * time.Millisecond)
+	require.True(t, ch.Send("fresh-1"))
+	clock.Advance(300 * time.Millisecond)
+	require.True(t, ch.Send("fresh-2"))
+	require.Equal(t, 4, ch.Len())
+
+	v, ok := ch.Receive()
+	require.True(t, ok)
+	require.Equal(t, "fresh-1", v)
+	require.Equal(t, []string{"old-1", "old-2"}, expired)
+	require.Equal(t, uint64(2), ch.Expired())
+
+	v, ok, err := ch.ReceiveContext(context.Background())
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "fresh-2", v)
+}
+
+func TestExpiringChanIter(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	ch := MakeExpiringChan[int](8, time.Second, WithClock(clock.Now))
+
+	for i := range 6 {
+		require.True(t, ch.Send(i))
+		clock.Advance(400 * time.Millisecond)
+	}
+	require.NoError(t, ch.Close())
+
+	var got []int
+	for v := range ch.Iter() {
+		got = append(got, v)
+	}
+	// Received at 2.4s, values sent at 1.6s and later are fresh.
+	require.Equal(t, []int{4, 5}, got)
+	require.Equal(t, uint64(4), ch.Expired())
+
+	_, ok := ch.Receive()
+	require.False(t, ok)
+}
+
+func TestExpiringChanHookType(t *testing.T) {
+	hook := WithExpireHook(func(int, time.Duration) {})
+	require.NotPanics(t, func() { MakeExpiringChan[int](1, time.Second, hook) })
+	require.Panics(t, func() { MakeExpiringChan[string](1, time.Second, hook) })
+}
+
+func TestExpiringChanRealClock(t *testing.T) {
+	ch := MakeExpiringChan[int](2, time.Hour)
+
+	require.True(t, ch.TrySend(1))
+	require.NoError(t, ch.SendContext(context.Background(), 2))
+	require.False(t, ch.TrySend(3))
+	require.Equal(t, 2, ch.Cap())
+
+	for i := range 2 {
+		v, ok := ch.Receive()
+		require.True(t, ok)
+		require.Equal(t, i+1, v)
+	}
+	require.Zero(t, ch.Expired())
+}
@@ -0,0 +1,130 @@
+package atomic
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Float64 is an atomic float64, implementing [Val].
+//
+// [Float64.CompareAndSwap] compares the bit patterns of the values, not their
+// numeric value like the == operator does :
+//   - a NaN matches a NaN with the same bit pattern, so that it can be replaced
+//   - -0 and +0 don't match
+//
+// The zero value is ready to use and holds +0. A [Float64] must not be copied
+// after first use.
+type Float64 struct {
+	v atomic.Uint64
+}
+
+// Load atomically loads and returns the value stored in f.
+func (f *Float64) Load() float64 {
+	return math.Float64frombits(f.v.Load())
+}
+
+// Store atomically stores val into f.
+func (f *Float64) Store(val float64) {
+	f.v.Store(math.Float64bits(val))
+}
+
+// Swap atomically stores new into f and returns the previous value.
+func (f *Float64) Swap(new float64) (old float64) {
+	return math.Float64frombits(f.v.Swap(math.Float64bits(new)))
+}
+
+// CompareAndSwap executes the compare-and-swap operation for f, comparing bit
+// patterns, see [Float64].
+func (f *Float64) CompareAndSwap(old, new float64) (swapped bool) {
+	return f.v.CompareAndSwap(math.Float64bits(old), math.Float64bits(new))
+}
+
+// Add atomically adds delta to f and returns the new value.
+func (f *Float64) Add(delta float64) (new float64) {
+	return f.update(func(old float64) float64 { return old + delta })
+}
+
+// Min atomically stores the minimum of f and val, as defined by [math.Min],
+// into f and returns it.
+func (f *Float64) Min(val float64) (new float64) {
+	return f.update(func(old float64) float64 { return math.Min(old, val) })
+}
+
+// Max atomically stores the maximum of f and val, as defined by [math.Max],
+// into f and returns it.
+func (f *Float64) Max(val float64) (new float64) {
+	return f.update(func(old float64) float64 { return math.Max(old, val) })
+}
+
+func (f *Float64) update(fn func(old float64) float64) float64 {
+	for {
+		old := f.v.Load()
+		new := math.Float64bits(fn(math.Float64frombits(old)))
+		if new == old || f.v.CompareAndSwap(old, new) {
+			return math.Float64frombits(new)
+		}
+	}
+}
+
+// Float32 is an atomic float32, implementing [Val].
+//
+// [Float32.CompareAndSwap] compares the bit patterns of the values, like
+// [Float64.CompareAndSwap].
+//
+// The zero value is ready to use and holds +0. A [Float32] must not be copied
+// after first use.
+type Float32 struct {
+	v atomic.Uint32
+}
+
+// Load atomically loads and returns the value stored in f.
+func (f *Float32) Load() float32 {
+	return math.Float32frombits(f.v.Load())
+}
+
+// Store atomically stores val into f.
+func (f *Float32) Store(val float32) {
+	f.v.Store(math.Float32bits(val))
+}
+
+// Swap atomically stores new into f and returns the previous value.
+func (f *Float32) Swap(new float32) (old float32) {
+	return math.Float32frombits(f.v.Swap(math.Float32bits(new)))
+}
+
+// CompareAndSwap executes the compare-and-swap operation for f, comparing bit
+// patterns, see [Float64].
+func (f *Float32) CompareAndSwap(old, new float32) (swapped bool) {
+	return f.v.CompareAndSwap(math.Float32bits(old), math.Float32bits(new))
+}
+
+// Add atomically adds delta to f and returns the new value.
+func (f *Float32) Add(delta float32) (new float32) {
+	return f.update(func(old float32) float32 { return old + delta })
+}
+
+// Min atomically stores the minimum of f and val, as defined by [math.Min],
+// into f and returns it.
+func (f *Float32) Min(val float32) (new float32) {
+	return f.update(func(old float32) float32 {
+		return float32(math.Min(float64(old), float64(val)))
+	})
+}
+
+// Max atomically stores the maximum of f and val, as defined by [math.Max],
+// into f and returns it.
+func (f *Float32) Max(val float32) (new float32) {
+	return f.update(func(old float32) float32 {
+		return float32(math.Max(float64(old), float64(val)))
+	})
+}
+
+func (f *Float32) update(fn func(old float32) float32) float32 {
+	for {
+		old := f.v.Load()
+		new := math.Float32bits(fn(math.Float32frombits(old)))
+		if new == old || f.v.CompareAndSwap(old, new) {
+			return math.Float32frombits(new)
+		}
+	}
+}
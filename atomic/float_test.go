@@ -0,0 +1,114 @@
+package atomic
+
+import (
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFloat64(t *testing.T) {
+	var f Float64
+	require.Equal(t, 0.0, f.Load())
+	require.False(t, math.Signbit(f.Load()))
+
+	f.Store(1.5)
+	require.Equal(t, 1.5, f.Load())
+	require.Equal(t, 1.5, f.Swap(2))
+	require.Equal(t, 4.5, f.Add(2.5))
+	require.Equal(t, 4.5, f.Max(3))
+	require.Equal(t, 6.0, f.Max(6))
+	require.Equal(t, -1.0, f.Min(-1))
+	require.Equal(t, -1.0, f.Min(0))
+
+	require.False(t, f.CompareAndSwap(1, 2))
+	require.True(t, f.CompareAndSwap(-1, 2))
+	require.Equal(t, 2.0, f.Load())
+}
+
+func TestFloat64CompareAndSwapBits(t *testing.T) {
+	var f Float64
+	nan := math.NaN()
+	f.Store(nan)
+	require.True(t, f.CompareAndSwap(nan, 1))
+	f.Store(nan)
+	require.False(t, f.CompareAndSwap(math.Float64frombits(math.Float64bits(nan)^1), 1))
+
+	negZero := math.Copysign(0, -1)
+	f.Store(negZero)
+	require.False(t, f.CompareAndSwap(0, 1))
+	require.True(t, f.CompareAndSwap(negZero, 1))
+	require.Equal(t, 1.0, f.Load())
+}
+
+func TestFloat64MinMaxSpecial(t *testing.T) {
+	var f Float64
+	require.True(t, math.Signbit(f.Min(math.Copysign(0, -1))))
+	require.False(t, math.Signbit(f.Max(0)))
+	require.True(t, math.IsNaN(f.Max(math.NaN())))
+	require.True(t, math.IsNaN(f.Min(1)))
+	f.Store(1)
+	require.True(t, math.IsInf(f.Max(math.Inf(1)), 1))
+}
+
+func TestFloat32(t *testing.T) {
+	var f Float32
+	f.Store(1.5)
+	require.Equal(t, float32(1.5), f.Swap(2))
+	require.Equal(t, float32(4.5), f.Add(2.5))
+	require.Equal(t, float32(6), f.Max(6))
+	require.Equal(t, float32(-1), f.Min(-1))
+
+	nan := float32(math.NaN())
+	f.Store(nan)
+	require.True(t, math.IsNaN(float64(f.Min(1))))
+	require.True(t, f.CompareAndSwap(nan, 1))
+	require.Equal(t, float32(1), f.Load())
+
+	negZero := float32(math.Copysign(0, -1))
+	f.Store(negZero)
+	require.False(t, f.CompareAndSwap(0, 1))
+	require.True(t, f.CompareAndSwap(negZero, 1))
+}
+
+func TestFloatConcurrent(t *testing.T) {
+	const (
+		goroutines = 8
+		adds       = 1000
+	)
+	var (
+		wg   sync.WaitGroup
+		sum  Float64
+		sum2 Float32
+		hi   Float64
+		lo   Float32
+	)
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range adds {
+				// Sums of small integers are exact.
+				sum.Add(1)
+				sum2.Add(0.5)
+				hi.Max(float64(g*adds + i))
+				lo.Min(-float32(g*adds + i))
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, float64(goroutines*adds), sum.Load())
+	require.Equal(t, float32(goroutines*adds)/2, sum2.Load())
+	require.Equal(t, float64(goroutines*adds-1), hi.Load())
+	require.Equal(t, -float32(goroutines*adds-1), lo.Load())
+}
+
+func BenchmarkFloat64Add(b *testing.B) {
+	var f Float64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			f.Add(1)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package atomic
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Int is an atomic integer of any type, implementing [Integer], including the
+// types not covered by the sync/atomic package (int, int8, uint16...) and named
+// integer types.
+//
+// Values are stored in a 64-bit word : 64-bit types use the native 64-bit
+// operations, and narrower types the native 32-bit operations on the low half
+// of the word. Types of 8 and 16 bits only hold the low bits of that half, the
+// high bits are ignored when loading, and compare-and-swap is done on the whole
+// half in a loop.
+//
+// The zero value is ready to use and holds 0. An [Int] must not be copied after
+// first use.
+type Int[T constraints.Integer] struct {
+	v atomic.Uint64
+}
+
+// wide reports whether T is stored on the whole 64-bit word.
+func (i *Int[T]) wide() bool {
+	return unsafe.Sizeof(T(0)) == 8
+}
+
+// narrow reports whether T is smaller than 32 bits.
+func (i *Int[T]) narrow() bool {
+	return unsafe.Sizeof(T(0)) < 4
+}
+
+// half returns the 32-bit word storing values narrower than 64 bits.
+func (i *Int[T]) half() *atomic.Uint32 {
+	return (*atomic.Uint32)(unsafe.Pointer(&i.v))
+}
+
+// Load atomically loads and returns the value stored in i.
+func (i *Int[T]) Load() T {
+	if i.wide() {
+		return T(i.v.Load())
+	}
+	return T(i.half().Load())
+}
+
+// Store atomically stores val into i.
+func (i *Int[T]) Store(val T) {
+	if i.wide() {
+		i.v.Store(uint64(val))
+		return
+	}
+	i.half().Store(uint32(val))
+}
+
+// Swap atomically stores new into i and returns the previous value.
+func (i *Int[T]) Swap(new T) (old T) {
+	if i.wide() {
+		return T(i.v.Swap(uint64(new)))
+	}
+	return T(i.half().Swap(uint32(new)))
+}
+
+// CompareAndSwap executes the compare-and-swap operation for i.
+func (i *Int[T]) CompareAndSwap(old, new T) (swapped bool) {
+	if i.wide() {
+		return i.v.CompareAndSwap(uint64(old), uint64(new))
+	}
+	if !i.narrow() {
+		return i.half().CompareAndSwap(uint32(old), uint32(new))
+	}
+	// The high bits of the half may differ from those of uint32(old) after an
+	// addition, compare the value itself.
+	for {
+		w := i.half().Load()
+		if T(w) != old {
+			return false
+		}
+		if i.half().CompareAndSwap(w, uint32(new)) {
+			return true
+		}
+	}
+}
+
+// Add atomically adds delta to i and returns the new value, wrapping around
+// like the + operator.
+func (i *Int[T]) Add(delta T) (new T) {
+	if i.wide() {
+		return T(i.v.Add(uint64(delta)))
+	}
+	return T(i.half().Add(uint32(delta)))
+}
+
+// And atomically performs a bitwise AND operation on i using the bitmask
+// provided as mask and returns the old value.
+func (i *Int[T]) And(mask T) (old T) {
+	if i.wide() {
+		return T(i.v.And(uint64(mask)))
+	}
+	return T(i.half().And(uint32(mask)))
+}
+
+// Or atomically performs a bitwise OR operation on i using the bitmask
+// provided as mask and returns the old value.
+func (i *Int[T]) Or(mask T) (old T) {
+	if i.wide() {
+		return T(i.v.Or(uint64(mask)))
+	}
+	return T(i.half().Or(uint32(mask)))
+}
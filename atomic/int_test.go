@@ -0,0 +1,204 @@
+package atomic
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/constraints"
+)
+
+type userID int64
+
+type flags uint8
+
+// testInt checks the operations of an [Int] against the Go operators, with
+// values spanning the whole range of T.
+func testInt[T constraints.Integer](t *testing.T, values ...T) {
+	t.Helper()
+	var i Int[T]
+	require.Zero(t, i.Load())
+	for _, a := range values {
+		for _, b := range values {
+			i.Store(a)
+			require.Equal(t, a, i.Load())
+			require.Equal(t, a+b, i.Add(b))
+			require.Equal(t, a+b, i.Load())
+
+			i.Store(a)
+			require.Equal(t, a, i.Swap(b))
+			require.Equal(t, b, i.Load())
+
+			i.Store(a)
+			require.Equal(t, a, i.And(b))
+			require.Equal(t, a&b, i.Load())
+			i.Store(a)
+			require.Equal(t, a, i.Or(b))
+			require.Equal(t, a|b, i.Load())
+
+			// Leaving high bits behind for the narrow types.
+			i.Store(a - 1)
+			i.Add(1)
+			require.Equal(t, a == b, i.CompareAndSwap(b, b+1))
+			if a == b {
+				require.Equal(t, b+1, i.Load())
+			} else {
+				require.Equal(t, a, i.Load())
+			}
+		}
+	}
+}
+
+func TestInt(t *testing.T) {
+	t.Run("int8", func(t *testing.T) {
+		testInt[int8](t, math.MinInt8, -1, 0, 1, 0x55, math.MaxInt8)
+	})
+	t.Run("int16", func(t *testing.T) {
+		testInt[int16](t, math.MinInt16, -1, 0, 1, 0x5555, math.MaxInt16)
+	})
+	t.Run("int32", func(t *testing.T) {
+		testInt[int32](t, math.MinInt32, -1, 0, 1, 0x55555555, math.MaxInt32)
+	})
+	t.Run("int64", func(t *testing.T) {
+		testInt[int64](t, math.MinInt64, -1, 0, 1, 0x5555555555555555, math.MaxInt64)
+	})
+	t.Run("int", func(t *testing.T) {
+		testInt[int](t, math.MinInt, -1, 0, 1, math.MaxInt)
+	})
+	t.Run("uint8", func(t *testing.T) {
+		testInt[uint8](t, 0, 1, 0xaa, math.MaxUint8)
+	})
+	t.Run("uint16", func(t *testing.T) {
+		testInt[uint16](t, 0, 1, 0xaaaa, math.MaxUint16)
+	})
+	t.Run("uint32", func(t *testing.T) {
+		testInt[uint32](t, 0, 1, 0xaaaaaaaa, math.MaxUint32)
+	})
+	t.Run("uint64", func(t *testing.T) {
+		testInt[uint64](t, 0, 1, 0xaaaaaaaaaaaaaaaa, math.MaxUint64)
+	})
+	t.Run("uintptr", func(t *testing.T) {
+		testInt[uintptr](t, 0, 1, ^uintptr(0))
+	})
+	t.Run("named", func(t *testing.T) {
+		testInt[userID](t, -1, 0, 42)
+		testInt[flags](t, 0, 1<<3, 0xff)
+	})
+}
+
+func TestIntConcurrentAdd(t *testing.T) {
+	const (
+		goroutines = 8
+		adds       = 1000
+	)
+	var (
+		wg  sync.WaitGroup
+		i8  Int[int8]
+		u16 Int[uint16]
+		i64 Int[int64]
+	)
+	for range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range adds {
+				i8.Add(1)
+				u16.Add(3)
+				i64.Add(-1)
+			}
+		}()
+	}
+	wg.Wait()
+	// The narrow values wrap around.
+	total := goroutines * adds
+	require.Equal(t, int8(total), i8.Load())
+	require.Equal(t, uint16(3*total), u16.Load())
+	require.Equal(t, int64(-total), i64.Load())
+}
+
+func TestIntConcurrentCompareAndSwap(t *testing.T) {
+	const goroutines = 8
+	var (
+		wg sync.WaitGroup
+		i  Int[uint8]
+	)
+	// Incrementing through CAS loops, racing with plain additions.
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				if g%2 == 0 {
+					i.Add(1)
+					continue
+				}
+				for {
+					old := i.Load()
+					if i.CompareAndSwap(old, old+1) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	total := goroutines * 1000
+	require.Equal(t, uint8(total), i.Load())
+}
+
+func TestIntConcurrentBits(t *testing.T) {
+	var (
+		wg sync.WaitGroup
+		i  Int[uint16]
+	)
+	for bit := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			i.Or(1 << bit)
+			if bit%2 == 1 {
+				i.And(^uint16(1 << bit))
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, uint16(0x5555), i.Load())
+}
+
+func BenchmarkIntAdd(b *testing.B) {
+	b.Run("sync/atomic.Int32", func(b *testing.B) {
+		var i atomic.Int32
+		for b.Loop() {
+			i.Add(1)
+		}
+	})
+	b.Run("Int[int32]", func(b *testing.B) {
+		var i Int[int32]
+		for b.Loop() {
+			i.Add(1)
+		}
+	})
+	b.Run("Int[int8]", func(b *testing.B) {
+		var i Int[int8]
+		for b.Loop() {
+			i.Add(1)
+		}
+	})
+}
+
+func BenchmarkIntCompareAndSwap(b *testing.B) {
+	b.Run("Int[int32]", func(b *testing.B) {
+		var i Int[int32]
+		for b.Loop() {
+			i.CompareAndSwap(i.Load(), 1)
+		}
+	})
+	b.Run("Int[int8]", func(b *testing.B) {
+		var i Int[int8]
+		for b.Loop() {
+			i.CompareAndSwap(i.Load(), 1)
+		}
+	})
+}
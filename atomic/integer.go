@@ -8,8 +8,13 @@ import (
 
 type Pointer[T any] = Val[*T]
 
-type Val[T any] interface {
+// Loader is the read-only part of [Val].
+type Loader[T any] interface {
 	Load() T
+}
+
+type Val[T any] interface {
+	Loader[T]
 	Store(val T)
 	Swap(new T) (old T)
 	CompareAndSwap(old T, new T) (swapped bool)
@@ -30,4 +35,25 @@ var (
 	_ Integer[uintptr] = (*atomic.Uintptr)(nil)
 	_ Val[bool]        = (*atomic.Bool)(nil)
 	_ Pointer[any]     = (*atomic.Pointer[any])(nil)
+	_ Integer[int]     = (*Int[int])(nil)
+	_ Integer[int8]    = (*Int[int8])(nil)
+	_ Integer[int16]   = (*Int[int16])(nil)
+	_ Integer[uint8]   = (*Int[uint8])(nil)
+	_ Integer[uint16]  = (*Int[uint16])(nil)
+	_ Integer[uint]    = (*Int[uint])(nil)
+	_ Val[float64]     = (*Float64)(nil)
+	_ Val[float32]     = (*Float32)(nil)
+	_ Val[any]         = valueVal[any]{}
+	_ Loader[int64]    = (*Counter)(nil)
+
+	_ Integer[int32]   = (*PaddedInt32)(nil)
+	_ Integer[int64]   = (*PaddedInt64)(nil)
+	_ Integer[uint32]  = (*PaddedUint32)(nil)
+	_ Integer[uint64]  = (*PaddedUint64)(nil)
+	_ Integer[uintptr] = (*PaddedUintptr)(nil)
+	_ Val[bool]        = (*PaddedBool)(nil)
+	_ Pointer[any]     = (*PaddedPointer[any])(nil)
+	_ Integer[int8]    = (*PaddedInt[int8])(nil)
+	_ Val[float64]     = (*PaddedFloat64)(nil)
+	_ Val[float32]     = (*PaddedFloat32)(nil)
 )
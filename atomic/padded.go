@@ -0,0 +1,78 @@
+package atomic
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"golang.org/x/exp/constraints"
+)
+
+// The Padded types wrap the atomic types, occupying a whole [CacheLineSize]
+// each. Values written by different goroutines, such as per-worker statistics
+// stored in an array, then don't share a cache line, avoiding false sharing :
+//
+//	stats := make([]PaddedUint64, workers)
+//	// In worker i.
+//	stats[i].Add(1)
+//
+// They implement the same interfaces as the types they wrap.
+
+// PaddedInt32 is an [atomic.Int32] occupying a whole cache line.
+type PaddedInt32 struct {
+	atomic.Int32
+	_ [CacheLineSize - unsafe.Sizeof(atomic.Int32{})]byte
+}
+
+// PaddedInt64 is an [atomic.Int64] occupying a whole cache line.
+type PaddedInt64 struct {
+	atomic.Int64
+	_ [CacheLineSize - unsafe.Sizeof(atomic.Int64{})]byte
+}
+
+// PaddedUint32 is an [atomic.Uint32] occupying a whole cache line.
+type PaddedUint32 struct {
+	atomic.Uint32
+	_ [CacheLineSize - unsafe.Sizeof(atomic.Uint32{})]byte
+}
+
+// PaddedUint64 is an [atomic.Uint64] occupying a whole cache line.
+type PaddedUint64 struct {
+	atomic.Uint64
+	_ [CacheLineSize - unsafe.Sizeof(atomic.Uint64{})]byte
+}
+
+// PaddedUintptr is an [atomic.Uintptr] occupying a whole cache line.
+type PaddedUintptr struct {
+	atomic.Uintptr
+	_ [CacheLineSize - unsafe.Sizeof(atomic.Uintptr{})]byte
+}
+
+// PaddedBool is an [atomic.Bool] occupying a whole cache line.
+type PaddedBool struct {
+	atomic.Bool
+	_ [CacheLineSize - unsafe.Sizeof(atomic.Bool{})]byte
+}
+
+// PaddedPointer is an [atomic.Pointer] occupying a whole cache line.
+type PaddedPointer[T any] struct {
+	atomic.Pointer[T]
+	_ [CacheLineSize - unsafe.Sizeof(atomic.Uintptr{})]byte
+}
+
+// PaddedInt is an [Int] occupying a whole cache line.
+type PaddedInt[T constraints.Integer] struct {
+	Int[T]
+	_ [CacheLineSize - unsafe.Sizeof(atomic.Uint64{})]byte
+}
+
+// PaddedFloat64 is a [Float64] occupying a whole cache line.
+type PaddedFloat64 struct {
+	Float64
+	_ [CacheLineSize - unsafe.Sizeof(Float64{})]byte
+}
+
+// PaddedFloat32 is a [Float32] occupying a whole cache line.
+type PaddedFloat32 struct {
+	Float32
+	_ [CacheLineSize - unsafe.Sizeof(Float32{})]byte
+}
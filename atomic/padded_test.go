@@ -0,0 +1,80 @@
+package atomic
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaddedSizes(t *testing.T) {
+	for _, size := range []uintptr{
+		unsafe.Sizeof(PaddedInt32{}),
+		unsafe.Sizeof(PaddedInt64{}),
+		unsafe.Sizeof(PaddedUint32{}),
+		unsafe.Sizeof(PaddedUint64{}),
+		unsafe.Sizeof(PaddedUintptr{}),
+		unsafe.Sizeof(PaddedBool{}),
+		unsafe.Sizeof(PaddedPointer[int]{}),
+		unsafe.Sizeof(PaddedInt[int8]{}),
+		unsafe.Sizeof(PaddedInt[uint64]{}),
+		unsafe.Sizeof(PaddedFloat64{}),
+		unsafe.Sizeof(PaddedFloat32{}),
+	} {
+		require.Equal(t, uintptr(CacheLineSize), size)
+	}
+}
+
+func TestPadded(t *testing.T) {
+	var (
+		wg    sync.WaitGroup
+		stats [4]PaddedUint64
+	)
+	for i := range stats {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				stats[i].Add(uint64(i))
+			}
+		}()
+	}
+	wg.Wait()
+	for i := range stats {
+		require.Equal(t, uint64(i*1000), stats[i].Load())
+	}
+
+	var p PaddedPointer[int]
+	v := 1
+	require.Nil(t, p.Swap(&v))
+	require.Same(t, &v, p.Load())
+
+	var f PaddedFloat64
+	require.Equal(t, 1.5, f.Add(1.5))
+}
+
+// benchmarkFalseSharing increments, from each goroutine, its own element of
+// counters.
+func benchmarkFalseSharing[C any](b *testing.B, counters []C, add func(*C)) {
+	var next atomic.Int64
+	b.SetParallelism(1)
+	b.RunParallel(func(pb *testing.PB) {
+		c := &counters[int(next.Add(1)-1)%len(counters)]
+		for pb.Next() {
+			add(c)
+		}
+	})
+}
+
+func BenchmarkFalseSharing(b *testing.B) {
+	b.Run("Uint64", func(b *testing.B) {
+		counters := make([]atomic.Uint64, 64)
+		benchmarkFalseSharing(b, counters, func(c *atomic.Uint64) { c.Add(1) })
+	})
+	b.Run("PaddedUint64", func(b *testing.B) {
+		counters := make([]PaddedUint64, 64)
+		benchmarkFalseSharing(b, counters, func(c *PaddedUint64) { c.Add(1) })
+	})
+}
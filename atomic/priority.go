@@ -0,0 +1,249 @@
+package atomic
+
+import (
+	"context"
+	"io"
+	"iter"
+	"sync"
+)
+
+// PriorityChan is a close-safe channel made of several priority lanes, each
+// with its own buffer. Lane 0 has the highest priority.
+//
+// Receive operations return a value from the highest priority non-empty lane,
+// so that urgent values (e.g. control messages) overtake the others. Lower
+// lanes can be protected from starvation with [WithFairness].
+//
+// Like a [CloseSafeChan], it can be closed by any goroutine while others are
+// sending : blocked senders are woken up and report it by returning false, and
+// receivers drain all the lanes before observing the [PriorityChan] as closed.
+type PriorityChan[T any] struct {
+	mu sync.Mutex
+	// nonEmpty is signaled when a value is queued, nonFull is broadcast when a
+	// value is dequeued. Both are broadcast on close.
+	nonEmpty sync.Cond
+	nonFull  sync.Cond
+	lanes    []priorityLane[T]
+	len      int
+	closed   bool
+}
+
+type priorityLane[T any] struct {
+	queue ringBuffer[T]
+	size  int
+	// weight is the number of times the lane can be passed over while
+	// non-empty before being served, 0 meaning unbounded. skipped counts
+	// them.
+	weight  int
+	skipped int
+}
+
+// PriorityChanOption configures a [PriorityChan], see [MakePriorityChan].
+type PriorityChanOption func(*priorityChanConfig)
+
+type priorityChanConfig struct {
+	weights []int
+}
+
+// WithFairness bounds the starvation of the lanes of a [PriorityChan] : while
+// lane i is non-empty, it is served after at most weights[i] values were
+// received from higher priority lanes.
+//
+// A weight of 0, as for lanes without weight, means the lane is only served
+// when all the higher priority lanes are empty.
+func WithFairness(weights ...int) PriorityChanOption {
+	return func(c *priorityChanConfig) { c.weights = weights }
+}
+
+// MakePriorityChan initializes a new [PriorityChan] instance with the given
+// number of lanes, each buffering up to size values.
+//
+// Lanes always have a buffer, levels and size lower than 1 are treated as 1.
+func MakePriorityChan[T any](levels, size int, opts ...PriorityChanOption) *PriorityChan[T] {
+	var cfg priorityChanConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	c := &PriorityChan[T]{
+		lanes: make([]priorityLane[T], max(levels, 1)),
+	}
+	for i := range c.lanes {
+		c.lanes[i].size = max(size, 1)
+		if i < len(cfg.weights) {
+			c.lanes[i].weight = cfg.weights[i]
+		}
+	}
+	c.nonEmpty.L = &c.mu
+	c.nonFull.L = &c.mu
+	return c
+}
+
+// Close closes the [PriorityChan] instance.
+//
+// It is safe to call it from concurrently running goroutines, and never
+// returns an error.
+func (c *PriorityChan[T]) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	c.nonEmpty.Broadcast()
+	c.nonFull.Broadcast()
+	return nil
+}
+
+// Send sends a value to the given lane of the [PriorityChan] instance,
+// blocking while its buffer is full.
+//
+// It returns true if the value was sent, false if the [PriorityChan] instance
+// is closed. It panics if priority isn't a valid lane.
+func (c *PriorityChan[T]) Send(priority int, value T) bool {
+	// Checked before locking, so that the panic leaves the lock free.
+	l := &c.lanes[priority]
+	c.mu.Lock()
+	for !c.closed && l.queue.Len() == l.size {
+		c.nonFull.Wait()
+	}
+	if c.closed {
+		c.mu.Unlock()
+		return false
+	}
+	l.queue.push(value)
+	c.len++
+	c.mu.Unlock()
+	c.nonEmpty.Signal()
+	return true
+}
+
+// Receive receives a value from the highest priority non-empty lane of the
+// [PriorityChan] instance, blocking until one is available.
+//
+// It returns the value, and a bool indicating if the [PriorityChan] instance
+// is open (true) or closed and drained (false).
+func (c *PriorityChan[T]) Receive() (T, bool) {
+	c.mu.Lock()
+	for c.len == 0 && !c.closed {
+		c.nonEmpty.Wait()
+	}
+	value, ok := c.pop()
+	c.mu.Unlock()
+	if ok {
+		c.nonFull.Broadcast()
+	}
+	return value, ok
+}
+
+// ReceiveContext is like [PriorityChan.Receive], but gives up when ctx is
+// done, returning the zero value, false and the context's error.
+//
+// Queued values are always received before giving up.
+func (c *PriorityChan[T]) ReceiveContext(ctx context.Context) (T, bool, error) {
+	c.mu.Lock()
+	if c.len == 0 && !c.closed {
+		stop := wakeOnDone(ctx, &c.nonEmpty)
+		defer stop()
+	}
+	for c.len == 0 && !c.closed {
+		if err := ctx.Err(); err != nil {
+			c.mu.Unlock()
+			return *new(T), false, err
+		}
+		c.nonEmpty.Wait()
+	}
+	value, ok := c.pop()
+	c.mu.Unlock()
+	if ok {
+		c.nonFull.Broadcast()
+	}
+	return value, ok, nil
+}
+
+// TryReceive receives a value from the [PriorityChan] instance without
+// blocking.
+//
+// The ok result reports whether a value was received, and the open result
+// whether the [PriorityChan] instance may still deliver values.
+func (c *PriorityChan[T]) TryReceive() (value T, ok, open bool) {
+	c.mu.Lock()
+	value, ok = c.pop()
+	open = ok || !c.closed
+	c.mu.Unlock()
+	if ok {
+		c.nonFull.Broadcast()
+	}
+	return value, ok, open
+}
+
+// pop dequeues a value from the lane to serve, the caller must hold the lock.
+func (c *PriorityChan[T]) pop() (T, bool) {
+	if c.len == 0 {
+		return *new(T), false
+	}
+	served := -1
+	for i := range c.lanes {
+		l := &c.lanes[i]
+		if l.queue.Len() == 0 {
+			continue
+		}
+		if served < 0 {
+			served = i
+			continue
+		}
+		// A starving lane overtakes the higher ones.
+		if l.weight > 0 && l.skipped >= l.weight {
+			served = i
+			break
+		}
+	}
+	for i := range c.lanes {
+		if i != served && c.lanes[i].queue.Len() > 0 {
+			c.lanes[i].skipped++
+		}
+	}
+	l := &c.lanes[served]
+	l.skipped = 0
+	c.len--
+	return l.queue.pop()
+}
+
+// Iter returns an iterator ranging on the values of the [PriorityChan]
+// instance in priority order, until it is closed and drained.
+func (c *PriorityChan[T]) Iter() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			value, ok := c.Receive()
+			if !ok || !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// IterContext is like [PriorityChan.Iter], but the iteration also stops once
+// ctx is done.
+func (c *PriorityChan[T]) IterContext(ctx context.Context) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			value, ok, err := c.ReceiveContext(ctx)
+			if err != nil || !ok || !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of values queued (unread) in all the lanes of the
+// [PriorityChan] instance.
+func (c *PriorityChan[T]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.len
+}
+
+// Levels returns the number of lanes of the [PriorityChan] instance.
+func (c *PriorityChan[T]) Levels() int {
+	return len(c.lanes)
+}
+
+var (
+	_ io.Closer = &PriorityChan[any]{}
+)
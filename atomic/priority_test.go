@@ -0,0 +1,183 @@
+package atomic
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestPriorityChanOrder(t *testing.T) {
+	ch := MakePriorityChan[string](3, 4)
+	require.Equal(t, 3, ch.Levels())
+
+	require.True(t, ch.Send(2, "low-1"))
+	require.True(t, ch.Send(1, "mid-1"))
+	require.True(t, ch.Send(2, "low-2"))
+	require.True(t, ch.Send(0, "high-1"))
+	require.True(t, ch.Send(1, "mid-2"))
+	require.Equal(t, 5, ch.Len())
+
+	require.NoError(t, ch.Close())
+	require.False(t, ch.Send(0, "high-2"))
+
+	var got []string
+	for v := range ch.Iter() {
+		got = append(got, v)
+	}
+	require.Equal(t, []string{"high-1", "mid-1", "mid-2", "low-1", "low-2"}, got)
+
+	_, ok, open := ch.TryReceive()
+	require.False(t, ok)
+	require.False(t, open)
+}
+
+func TestPriorityChanFairness(t *testing.T) {
+	ch := MakePriorityChan[int](2, 16, WithFairness(0, 2))
+
+	for i := range 6 {
+		require.True(t, ch.Send(0, i))
+	}
+	for i := range 3 {
+		require.True(t, ch.Send(1, 100+i))
+	}
+	require.NoError(t, ch.Close())
+
+	var got []int
+	for v := range ch.Iter() {
+		got = append(got, v)
+	}
+	require.Equal(t, []int{0, 1, 100, 2, 3, 101, 4, 5, 102}, got)
+}
+
+func TestPriorityChanSendBlocksPerLane(t *testing.T) {
+	ch := MakePriorityChan[int](2, 1)
+
+	require.True(t, ch.Send(1, 1))
+	// The other lane has its own buffer.
+	require.True(t, ch.Send(0, 0))
+
+	sent := make(chan bool)
+	go func() { sent <- ch.Send(1, 2) }()
+
+	select {
+	case <-sent:
+		t.Fatal("Send did not block on a full lane")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	v, ok := ch.Receive()
+	require.True(t, ok)
+	require.Equal(t, 0, v)
+	v, ok = ch.Receive()
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+	require.True(t, <-sent)
+}
+
+func TestPriorityChanCloseWakesBlockedSenders(t *testing.T) {
+	ch := MakePriorityChan[int](1, 1)
+	require.True(t, ch.Send(0, 0))
+
+	sent := make(chan bool)
+	go func() { sent <- ch.Send(0, 1) }()
+
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, ch.Close())
+	require.False(t, <-sent)
+
+	v, ok := ch.Receive()
+	require.True(t, ok)
+	require.Equal(t, 0, v)
+	_, ok = ch.Receive()
+	require.False(t, ok)
+}
+
+func TestPriorityChanInvalidLane(t *testing.T) {
+	ch := MakePriorityChan[int](2, 1)
+	require.Panics(t, func() { ch.Send(2, 0) })
+	require.Panics(t, func() { ch.Send(-1, 0) })
+	require.Panics(t, func() { ch.Lane(2) })
+
+	// The panics left the instance usable.
+	require.True(t, ch.Send(1, 1))
+	require.Equal(t, 1, ch.Len())
+	require.NoError(t, ch.Close())
+	v, ok := ch.Receive()
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+}
+
+func TestPriorityChanReceiveContext(t *testing.T) {
+	ch := MakePriorityChan[int](2, 1)
+	require.True(t, ch.Send(1, 1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	v, ok, err := ch.ReceiveContext(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+	_, ok, err = ch.ReceiveContext(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	require.False(t, ok)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err = ch.ReceiveContext(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Receiving frees room for blocked senders.
+	require.True(t, ch.Send(0, 2))
+	go func() {
+		ch.Send(0, 3)
+		ch.Close()
+	}()
+	require.Equal(t, []int{2, 3}, slices.Collect(ch.IterContext(context.Background())))
+}
+
+func TestPriorityChanConcurrentSendsReceivesCloses(t *testing.T) {
+	const (
+		nbLevels    = 4
+		nbReceivers = 4
+		nbSenders   = 16
+		nbSend      = 256
+	)
+
+	ch := MakePriorityChan[int](nbLevels, 8, WithFairness(0, 4, 4, 4))
+
+	var sent, skipped, received atomic.Uint32
+	var receivers, senders errgroup.Group
+	for range nbReceivers {
+		receivers.Go(func() error {
+			for range ch.Iter() {
+				received.Add(1)
+			}
+			return nil
+		})
+	}
+	for i := range nbSenders {
+		senders.Go(func() error {
+			for j := range nbSend {
+				if ch.Send((i+j)%nbLevels, j) {
+					sent.Add(1)
+				} else {
+					skipped.Add(1)
+				}
+			}
+			return nil
+		})
+	}
+
+	time.Sleep(time.Millisecond)
+	require.NoError(t, ch.Close())
+	require.NoError(t, senders.Wait())
+	require.NoError(t, receivers.Wait())
+
+	require.Equal(t, sent.Load(), received.Load())
+	require.Equal(t, uint32(nbSenders*nbSend), sent.Load()+skipped.Load())
+}
@@ -0,0 +1,55 @@
+package atomic
+
+// minRingBufferCap is the capacity a ringBuffer starts with, and never shrinks
+// below.
+const minRingBufferCap = 16
+
+// ringBuffer is a FIFO queue backed by a circular slice, growing when full and
+// shrinking when mostly empty. It is not concurrency-safe.
+type ringBuffer[T any] struct {
+	buf  []T
+	head int
+	len  int
+}
+
+func (r *ringBuffer[T]) Len() int {
+	return r.len
+}
+
+func (r *ringBuffer[T]) push(value T) {
+	if r.len == len(r.buf) {
+		r.resize(max(2*len(r.buf), minRingBufferCap))
+	}
+	r.buf[(r.head+r.len)%len(r.buf)] = value
+	r.len++
+}
+
+func (r *ringBuffer[T]) pop() (T, bool) {
+	if r.len == 0 {
+		return *new(T), false
+	}
+	value := r.buf[r.head]
+	// Clearing the slot, so that the buffer doesn't retain popped values.
+	r.buf[r.head] = *new(T)
+	r.head = (r.head + 1) % len(r.buf)
+	r.len--
+	if len(r.buf) > minRingBufferCap && r.len <= len(r.buf)/4 {
+		r.resize(len(r.buf) / 2)
+	}
+	return value, true
+}
+
+// resize moves the queued values to the front of a new slice of size n.
+func (r *ringBuffer[T]) resize(n int) {
+	buf := make([]T, n)
+	if r.len > 0 {
+		if tail := r.head + r.len; tail <= len(r.buf) {
+			copy(buf, r.buf[r.head:tail])
+		} else {
+			m := copy(buf, r.buf[r.head:])
+			copy(buf[m:], r.buf[:tail-len(r.buf)])
+		}
+	}
+	r.buf = buf
+	r.head = 0
+}
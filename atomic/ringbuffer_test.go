@@ -0,0 +1,38 @@
+package atomic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRingBufferFIFO(t *testing.T) {
+	var r ringBuffer[int]
+
+	_, ok := r.pop()
+	require.False(t, ok)
+
+	// Interleaving pushes and pops so that the queue wraps around while
+	// growing and shrinking.
+	var next, expected int
+	for round := range 8 {
+		for range 100 * (round + 1) {
+			r.push(next)
+			next++
+		}
+		for range 60 * (round + 1) {
+			v, ok := r.pop()
+			require.True(t, ok)
+			require.Equal(t, expected, v)
+			expected++
+		}
+	}
+	for r.Len() > 0 {
+		v, _ := r.pop()
+		require.Equal(t, expected, v)
+		expected++
+	}
+
+	require.Equal(t, next, expected)
+	require.Equal(t, minRingBufferCap, len(r.buf))
+}
@@ -0,0 +1,207 @@
+package atomic
+
+import (
+	"context"
+	"reflect"
+)
+
+// SelectCase is a case of [Select], built by [SelectRecv] or [SelectSend].
+//
+// A case can be passed to successive [Select] calls. Once it reported its
+// channel as closed, it is disabled and ignored by the following calls.
+type SelectCase interface {
+	// appendCases appends the reflect cases waited on by the case to dst.
+	appendCases(dst []reflect.SelectCase) []reflect.SelectCase
+	// enter prepares the case before waiting, returning false if it fired
+	// right away. Every successful enter call is followed by a leave call.
+	enter() bool
+	leave()
+	// fire records the result of the i-th reflect case of the case.
+	fire(i int, value reflect.Value, ok bool)
+	disabled() bool
+	// blocking returns the diagnostics of the channel of the case, nil if
+	// they are disabled, and the kind of operation the case blocks in.
+	blocking() (*chanDebug, blockedKind)
+}
+
+// RecvCase is a [SelectCase] receiving from a [CloseSafeChan].
+type RecvCase[T any] struct {
+	c *CloseSafeChan[T]
+	// Value is the value received, when the case fired with Open true.
+	Value T
+	// Open is false if the case fired because the channel is closed and
+	// drained.
+	Open bool
+}
+
+// SelectRecv returns a [SelectCase] receiving from c.
+func SelectRecv[T any](c *CloseSafeChan[T]) *RecvCase[T] {
+	return &RecvCase[T]{c: c, Open: true}
+}
+
+func (r *RecvCase[T]) appendCases(dst []reflect.SelectCase) []reflect.SelectCase {
+	return append(dst, reflect.SelectCase{
+		Dir:  reflect.SelectRecv,
+		Chan: reflect.ValueOf(r.c.ch),
+	})
+}
+
+func (r *RecvCase[T]) enter() bool { return true }
+
+func (r *RecvCase[T]) leave() {}
+
+func (r *RecvCase[T]) fire(_ int, value reflect.Value, ok bool) {
+	reflect.ValueOf(&r.Value).Elem().Set(value)
+	r.Open = ok
+}
+
+func (r *RecvCase[T]) disabled() bool { return !r.Open }
+
+func (r *RecvCase[T]) blocking() (*chanDebug, blockedKind) { return r.c.debug, blockedReceive }
+
+// SendCase is a [SelectCase] sending a value to a [CloseSafeChan].
+//
+// It follows the close-safety protocol of [CloseSafeChan.Send], but not its
+// [Backpressure] policy : it fires once the value is sent or once the channel
+// starts closing.
+type SendCase[T any] struct {
+	c *CloseSafeChan[T]
+	// Value is the value to send, it can be changed between [Select] calls.
+	Value T
+	// Closed is true if the case fired because the channel is closing or
+	// closed, in which case Value wasn't sent.
+	Closed bool
+}
+
+// SelectSend returns a [SelectCase] sending value to c.
+func SelectSend[T any](c *CloseSafeChan[T], value T) *SendCase[T] {
+	return &SendCase[T]{c: c, Value: value}
+}
+
+func (s *SendCase[T]) appendCases(dst []reflect.SelectCase) []reflect.SelectCase {
+	return append(dst,
+		reflect.SelectCase{
+			Dir:  reflect.SelectSend,
+			Chan: reflect.ValueOf(s.c.ch),
+			Send: reflect.ValueOf(&s.Value).Elem(),
+		},
+		reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(s.c.done),
+		},
+	)
+}
+
+func (s *SendCase[T]) enter() bool {
+	// Registering as sending, so that the underlying channel stays open until
+	// leave is called.
+	s.c.sending.Add(1)
+	if s.c.state.Current() != stateOpen {
+		s.c.release()
+		s.Closed = true
+		return false
+	}
+	return true
+}
+
+func (s *SendCase[T]) leave() {
+	s.c.release()
+}
+
+func (s *SendCase[T]) fire(i int, _ reflect.Value, _ bool) {
+	// The second reflect case is the done channel.
+	s.Closed = i == 1
+}
+
+func (s *SendCase[T]) disabled() bool { return s.Closed }
+
+func (s *SendCase[T]) blocking() (*chanDebug, blockedKind) { return s.c.debug, blockedSend }
+
+// Select waits until one of the cases can proceed, or until ctx is done, and
+// returns the index of the case that fired. Like a select statement, it picks
+// one at random if several can proceed.
+//
+// The results are found in the fired case : [RecvCase.Value] and
+// [RecvCase.Open], or [SendCase.Closed]. Closed channels are reported once,
+// the case is then disabled and ignored by the following calls.
+//
+// It returns -1 and the context's error if ctx is done first, and -1 and
+// [ErrClosed] if all the cases are disabled.
+//
+// While it blocks, it is recorded by the channels created with [WithDebug] as
+// blocked in a send or receive operation, according to the case.
+//
+// It relies on [reflect.Select], and is several times slower than a select
+// statement on raw channels.
+func Select(ctx context.Context, cases ...SelectCase) (int, error) {
+	var (
+		rcases = make([]reflect.SelectCase, 0, 2*len(cases)+1)
+		owners = make([]int, 0, 2*len(cases)+1)
+		starts = make([]int, len(cases))
+	)
+	for i := range starts {
+		starts[i] = -1
+	}
+	defer func() {
+		for i, c := range cases {
+			if starts[i] >= 0 {
+				c.leave()
+			}
+		}
+	}()
+	for i, c := range cases {
+		if c.disabled() {
+			continue
+		}
+		if !c.enter() {
+			return i, nil
+		}
+		starts[i] = len(rcases)
+		rcases = c.appendCases(rcases)
+		for len(owners) < len(rcases) {
+			owners = append(owners, i)
+		}
+	}
+	if len(rcases) == 0 {
+		return -1, ErrClosed
+	}
+	if done := ctx.Done(); done != nil {
+		rcases = append(rcases, reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(done),
+		})
+	}
+
+	fired := func(chosen int, value reflect.Value, ok bool) (int, error) {
+		if chosen == len(owners) {
+			return -1, ctx.Err()
+		}
+		i := owners[chosen]
+		cases[i].fire(chosen-starts[i], value, ok)
+		return i, nil
+	}
+	if debugged(cases, starts) {
+		// Like the operations of a CloseSafeChan, Select is only recorded as
+		// blocked if no case can proceed right away.
+		chosen, value, ok := reflect.Select(append(rcases, reflect.SelectCase{Dir: reflect.SelectDefault}))
+		if chosen < len(rcases) {
+			return fired(chosen, value, ok)
+		}
+		for i, c := range cases {
+			if d, kind := c.blocking(); starts[i] >= 0 && d != nil {
+				defer d.leave(d.enter(kind))
+			}
+		}
+	}
+	return fired(reflect.Select(rcases))
+}
+
+// debugged reports whether a case waited on has its diagnostics enabled.
+func debugged(cases []SelectCase, starts []int) bool {
+	for i, c := range cases {
+		if d, _ := c.blocking(); starts[i] >= 0 && d != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package atomic_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wazazaby/gs/atomic"
+)
+
+func ExampleSelect() {
+	numbers := atomic.MakeCloseSafeChan[int](2)
+	words := atomic.MakeCloseSafeChan[string](2)
+
+	_ = numbers.Send(1)
+	_ = words.Send("foo")
+	_ = numbers.Close()
+	_ = words.Close()
+
+	n, w := atomic.SelectRecv(numbers), atomic.SelectRecv(words)
+	var got []string
+	for {
+		i, err := atomic.Select(context.Background(), n, w)
+		if err != nil {
+			fmt.Println(err)
+			break
+		}
+		switch {
+		case i == 0 && n.Open:
+			got = append(got, fmt.Sprint("number ", n.Value))
+		case i == 1 && w.Open:
+			got = append(got, "word "+w.Value)
+		default:
+			got = append(got, fmt.Sprint("case ", i, " closed"))
+		}
+	}
+	fmt.Println(len(got))
+
+	// Output:
+	// atomic: channel is closed
+	// 4
+}
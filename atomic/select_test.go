@@ -0,0 +1,153 @@
+package atomic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectRecv(t *testing.T) {
+	a, b := MakeCloseSafeChan[int](1), MakeCloseSafeChan[string](1)
+	ra, rb := SelectRecv(a), SelectRecv(b)
+
+	require.True(t, b.Send("foo"))
+	i, err := Select(context.Background(), ra, rb)
+	require.NoError(t, err)
+	require.Equal(t, 1, i)
+	require.True(t, rb.Open)
+	require.Equal(t, "foo", rb.Value)
+
+	require.True(t, a.Send(42))
+	i, err = Select(context.Background(), ra, rb)
+	require.NoError(t, err)
+	require.Equal(t, 0, i)
+	require.Equal(t, 42, ra.Value)
+}
+
+func TestSelectClosed(t *testing.T) {
+	a, b := MakeCloseSafeChan[int](), MakeCloseSafeChan[int]()
+	ra, rb := SelectRecv(a), SelectRecv(b)
+
+	require.NoError(t, a.Close())
+	i, err := Select(context.Background(), ra, rb)
+	require.NoError(t, err)
+	require.Equal(t, 0, i)
+	require.False(t, ra.Open)
+
+	// The closed case is now ignored.
+	go func() { _ = b.Send(1) }()
+	i, err = Select(context.Background(), ra, rb)
+	require.NoError(t, err)
+	require.Equal(t, 1, i)
+	require.Equal(t, 1, rb.Value)
+
+	require.NoError(t, b.Close())
+	i, err = Select(context.Background(), ra, rb)
+	require.NoError(t, err)
+	require.Equal(t, 1, i)
+	require.False(t, rb.Open)
+
+	i, err = Select(context.Background(), ra, rb)
+	require.ErrorIs(t, err, ErrClosed)
+	require.Equal(t, -1, i)
+}
+
+func TestSelectContext(t *testing.T) {
+	ch := MakeCloseSafeChan[int]()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	i, err := Select(ctx, SelectRecv(ch), SelectSend(ch, 1))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, -1, i)
+	require.Zero(t, ch.sending.Load())
+}
+
+func TestSelectSend(t *testing.T) {
+	full, ready := MakeCloseSafeChan[int](), MakeCloseSafeChan[any](1)
+	sf, sr := SelectSend(full, 1), SelectSend[any](ready, nil)
+
+	i, err := Select(context.Background(), sf, sr)
+	require.NoError(t, err)
+	require.Equal(t, 1, i)
+	require.False(t, sr.Closed)
+
+	v, ok := ready.Receive()
+	require.True(t, ok)
+	require.Nil(t, v)
+	require.Zero(t, full.sending.Load())
+}
+
+func TestSelectSendClose(t *testing.T) {
+	ch := MakeCloseSafeChan[int]()
+	sc := SelectSend(ch, 1)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		require.NoError(t, ch.Close())
+	}()
+
+	i, err := Select(context.Background(), sc)
+	require.NoError(t, err)
+	require.Equal(t, 0, i)
+	require.True(t, sc.Closed)
+
+	i, err = Select(context.Background(), sc)
+	require.ErrorIs(t, err, ErrClosed)
+	require.Equal(t, -1, i)
+
+	// A case built on an already closed channel fires right away.
+	sc = SelectSend(ch, 2)
+	i, err = Select(context.Background(), SelectRecv(MakeCloseSafeChan[int]()), sc)
+	require.NoError(t, err)
+	require.Equal(t, 1, i)
+	require.True(t, sc.Closed)
+	require.Zero(t, ch.sending.Load())
+}
+
+func BenchmarkSelectStatement(b *testing.B) {
+	b.ReportAllocs()
+
+	a, c := make(chan int, 1), make(chan int, 1)
+	ctx := context.Background()
+
+	for i := range b.N {
+		a <- i
+		select {
+		case <-a:
+		case <-c:
+		case <-ctx.Done():
+		}
+	}
+}
+
+func BenchmarkSelect(b *testing.B) {
+	b.ReportAllocs()
+
+	a, c := MakeCloseSafeChan[int](1), MakeCloseSafeChan[int](1)
+	ra, rc := SelectRecv(a), SelectRecv(c)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for i := range b.N {
+		_ = a.Send(i)
+		_, _ = Select(ctx, ra, rc)
+	}
+}
+
+func BenchmarkSelectSend(b *testing.B) {
+	b.ReportAllocs()
+
+	a, c := MakeCloseSafeChan[int](1), MakeCloseSafeChan[int](1)
+	sa, rc := SelectSend(a, 0), SelectRecv(c)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for range b.N {
+		_, _ = Select(ctx, sa, rc)
+		_, _ = a.Receive()
+	}
+}
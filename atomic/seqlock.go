@@ -0,0 +1,157 @@
+package atomic
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// SeqLock is a sequence lock protecting a value of type T, for values read
+// much more often than written.
+//
+// Loads don't lock nor allocate : they copy the value optimistically, and
+// retry if a store ran concurrently. Stores are serialized by a mutex, and
+// don't allocate either, unlike [Value.Store].
+//
+// T must not contain pointers (including strings, slices, maps and
+// interfaces), as the value is copied word by word, possibly while being
+// written. The words are accessed atomically, so that a [SeqLock] is safe for
+// the race detector.
+//
+// The zero value is ready to use and holds the zero value of T. Using a
+// [SeqLock] of a type T containing pointers panics. A [SeqLock] must not be
+// copied after first use.
+type SeqLock[T any] struct {
+	once sync.Once
+	// invalid is the panic message of every operation if T contains pointers.
+	invalid string
+	mu      sync.Mutex
+	// seq is odd while a store is running, and incremented twice by each.
+	seq   atomic.Uint64
+	words []atomic.Uint64
+	// wordwise reports whether values of type T can be accessed as uint64
+	// words directly, instead of byte by byte.
+	wordwise bool
+}
+
+// NewSeqLock returns a [SeqLock] holding val.
+//
+// It panics if T contains pointers.
+func NewSeqLock[T any](val T) *SeqLock[T] {
+	l := &SeqLock[T]{}
+	l.Store(val)
+	return l
+}
+
+// lazyInit allocates the words on first use, panicking if T contains pointers.
+func (l *SeqLock[T]) lazyInit() {
+	l.once.Do(func() {
+		typ := reflect.TypeFor[T]()
+		if !pointerFree(typ) {
+			l.invalid = fmt.Sprintf("atomic: SeqLock of %s, which contains pointers", typ)
+			return
+		}
+		l.words = make([]atomic.Uint64, (typ.Size()+7)/8)
+		l.wordwise = typ.Size()%8 == 0 && typ.Align()%8 == 0
+	})
+	if l.invalid != "" {
+		panic(l.invalid)
+	}
+}
+
+// pointerFree reports whether values of type typ hold no pointers.
+func pointerFree(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	case reflect.Array:
+		return typ.Len() == 0 || pointerFree(typ.Elem())
+	case reflect.Struct:
+		for i := range typ.NumField() {
+			if !pointerFree(typ.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// bytesOf returns the memory of *val.
+func bytesOf[T any](val *T) []byte {
+	return unsafe.Slice((*byte)(unsafe.Pointer(val)), unsafe.Sizeof(*val))
+}
+
+// wordsOf returns the memory of *val as words, which requires the size and
+// alignment of T to be multiples of 8.
+func wordsOf[T any](val *T) []uint64 {
+	return unsafe.Slice((*uint64)(unsafe.Pointer(val)), unsafe.Sizeof(*val)/8)
+}
+
+// Load returns a copy of the value.
+//
+// It retries while stores are running, yielding the processor to them.
+func (l *SeqLock[T]) Load() T {
+	l.lazyInit()
+	var val T
+	for spins := 0; ; spins++ {
+		seq := l.seq.Load()
+		if seq%2 == 0 {
+			l.read(&val)
+			if l.seq.Load() == seq {
+				return val
+			}
+		}
+		if spins > 0 {
+			runtime.Gosched()
+		}
+	}
+}
+
+// Store sets the value to val.
+func (l *SeqLock[T]) Store(val T) {
+	l.lazyInit()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.store(&val)
+}
+
+func (l *SeqLock[T]) store(val *T) {
+	l.seq.Add(1)
+	if l.wordwise {
+		for i, w := range wordsOf(val) {
+			l.words[i].Store(w)
+		}
+	} else {
+		b := bytesOf(val)
+		for i := range l.words {
+			var w uint64
+			copy((*[8]byte)(unsafe.Pointer(&w))[:], b[i*8:])
+			l.words[i].Store(w)
+		}
+	}
+	l.seq.Add(1)
+}
+
+// read copies the words into val, which may be torn by a concurrent store.
+func (l *SeqLock[T]) read(val *T) {
+	if l.wordwise {
+		words := wordsOf(val)
+		for i := range words {
+			words[i] = l.words[i].Load()
+		}
+		return
+	}
+	b := bytesOf(val)
+	for i := range l.words {
+		w := l.words[i].Load()
+		copy(b[i*8:], (*[8]byte)(unsafe.Pointer(&w))[:])
+	}
+}
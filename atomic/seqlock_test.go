@@ -0,0 +1,166 @@
+package atomic
+
+import (
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+type rates struct {
+	limit  float64
+	burst  int32
+	window [3]int16
+	on     bool
+}
+
+type window struct {
+	start, end int64
+	count      uint32
+}
+
+func TestSeqLock(t *testing.T) {
+	l := NewSeqLock(rates{limit: 1.5, burst: 10})
+	require.Equal(t, rates{limit: 1.5, burst: 10}, l.Load())
+
+	want := rates{limit: 2, burst: -3, window: [3]int16{1, 2, 3}, on: true}
+	l.Store(want)
+	require.Equal(t, want, l.Load())
+
+	// Sizes not multiple of a word.
+	b := NewSeqLock([5]byte{1, 2, 3, 4, 5})
+	require.Equal(t, [5]byte{1, 2, 3, 4, 5}, b.Load())
+	b.Store([5]byte{5, 4, 3, 2, 1})
+	require.Equal(t, [5]byte{5, 4, 3, 2, 1}, b.Load())
+
+	// Sizes multiple of a word, where int64 is aligned on a word.
+	w := NewSeqLock(window{start: 1, end: 2, count: 3})
+	require.Equal(t, unsafe.Alignof(int64(0)) == 8, w.wordwise)
+	require.Equal(t, window{start: 1, end: 2, count: 3}, w.Load())
+	w.Store(window{start: -1})
+	require.Equal(t, window{start: -1}, w.Load())
+
+	empty := NewSeqLock(struct{}{})
+	require.Equal(t, struct{}{}, empty.Load())
+}
+
+func TestSeqLockPointers(t *testing.T) {
+	require.Panics(t, func() { NewSeqLock("foo") })
+	var zero SeqLock[string]
+	require.Panics(t, func() { zero.Load() })
+	require.Panics(t, func() { zero.Store("foo") })
+	require.Panics(t, func() { zero.Load() })
+	require.Panics(t, func() { NewSeqLock([]int{}) })
+	require.Panics(t, func() { NewSeqLock(struct{ p *int }{}) })
+	require.Panics(t, func() { NewSeqLock([1]any{}) })
+	require.NotPanics(t, func() { NewSeqLock([0]*int{}) })
+}
+
+func TestSeqLockZeroValue(t *testing.T) {
+	var l SeqLock[rates]
+	require.Equal(t, rates{}, l.Load())
+	l.Store(rates{limit: 3, on: true})
+	require.Equal(t, rates{limit: 3, on: true}, l.Load())
+
+	var b SeqLock[[3]byte]
+	b.Store([3]byte{1, 2, 3})
+	require.Equal(t, [3]byte{1, 2, 3}, b.Load())
+}
+
+func TestSeqLockAllocs(t *testing.T) {
+	l := NewSeqLock(rates{})
+	require.Zero(t, testing.AllocsPerRun(100, func() {
+		l.Store(rates{limit: 1})
+		_ = l.Load()
+	}))
+}
+
+func TestSeqLockConcurrent(t *testing.T) {
+	// All the fields of a stored value are equal, a torn read would mix them.
+	type quad [4]uint64
+	l := NewSeqLock(quad{})
+
+	var wg sync.WaitGroup
+	for w := range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range uint64(2000) {
+				v := i*2 + uint64(w)
+				l.Store(quad{v, v, v, v})
+			}
+		}()
+	}
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 2000 {
+				q := l.Load()
+				require.Equal(t, quad{q[0], q[0], q[0], q[0]}, q)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkSeqLockLoad(b *testing.B) {
+	b.Run("SeqLock", func(b *testing.B) {
+		l := NewSeqLock(rates{limit: 1})
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_ = l.Load()
+			}
+		})
+	})
+	b.Run("Value", func(b *testing.B) {
+		var v Value[rates]
+		v.Store(rates{limit: 1})
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_, _ = v.Load()
+			}
+		})
+	})
+	b.Run("RWMutex", func(b *testing.B) {
+		var (
+			mu sync.RWMutex
+			r  = rates{limit: 1}
+		)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				mu.RLock()
+				_ = r
+				mu.RUnlock()
+			}
+		})
+	})
+}
+
+func BenchmarkSeqLockStore(b *testing.B) {
+	b.Run("SeqLock", func(b *testing.B) {
+		l := NewSeqLock(rates{})
+		for b.Loop() {
+			l.Store(rates{limit: 1})
+		}
+	})
+	b.Run("Value", func(b *testing.B) {
+		var v Value[rates]
+		for b.Loop() {
+			v.Store(rates{limit: 1})
+		}
+	})
+	b.Run("RWMutex", func(b *testing.B) {
+		var (
+			mu sync.RWMutex
+			r  rates
+		)
+		for b.Loop() {
+			mu.Lock()
+			r = rates{limit: 1}
+			mu.Unlock()
+		}
+		_ = r
+	})
+}
@@ -0,0 +1,119 @@
+package atomic
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// StateMachine is a state of type S, moving between states through the
+// transitions allowed by a table, safe to use from concurrently running
+// goroutines.
+//
+// A transition only succeeds from the expected state, so that among
+// goroutines racing on the same transition, only one wins :
+//
+//	const (
+//		idle State = iota
+//		running
+//		stopped
+//	)
+//	m := NewStateMachine(idle, map[State][]State{
+//		idle:    {running, stopped},
+//		running: {stopped},
+//	})
+//	if m.Transition(idle, running) {
+//		// Only one goroutine gets there.
+//	}
+type StateMachine[S ~uint32] struct {
+	state atomic.Uint32
+	// allowed holds the allowed transitions, it may be shared by several
+	// state machines and is never modified.
+	allowed map[[2]S]struct{}
+	hooks   []func(from, to S)
+	// changed is closed and cleared by each transition. It is created lazily
+	// by [StateMachine.WaitFor], so that transitions don't allocate when
+	// nobody waits.
+	changed atomic.Pointer[chan struct{}]
+}
+
+// NewStateMachine returns a [StateMachine] in the initial state, allowing the
+// transitions from each state of the transitions table to the states it maps
+// to.
+//
+// The hooks are called after each transition, see
+// [StateMachine.Transition].
+func NewStateMachine[S ~uint32](initial S, transitions map[S][]S, hooks ...func(from, to S)) *StateMachine[S] {
+	m := &StateMachine[S]{}
+	m.init(initial, allowedTransitions(transitions), hooks)
+	return m
+}
+
+// allowedTransitions flattens a transitions table.
+func allowedTransitions[S ~uint32](transitions map[S][]S) map[[2]S]struct{} {
+	allowed := make(map[[2]S]struct{})
+	for from, tos := range transitions {
+		for _, to := range tos {
+			allowed[[2]S{from, to}] = struct{}{}
+		}
+	}
+	return allowed
+}
+
+func (m *StateMachine[S]) init(initial S, allowed map[[2]S]struct{}, hooks []func(from, to S)) {
+	m.state.Store(uint32(initial))
+	m.allowed = allowed
+	m.hooks = hooks
+}
+
+// Current returns the current state.
+func (m *StateMachine[S]) Current() S {
+	return S(m.state.Load())
+}
+
+// Transition moves from the from state to the to state, if the transition is
+// allowed and the current state is from.
+//
+// It reports whether it moved. When it does, the hooks are called in order by
+// the calling goroutine, before Transition returns.
+func (m *StateMachine[S]) Transition(from, to S) bool {
+	if _, ok := m.allowed[[2]S{from, to}]; !ok {
+		return false
+	}
+	if !m.state.CompareAndSwap(uint32(from), uint32(to)) {
+		return false
+	}
+	if changed := m.changed.Swap(nil); changed != nil {
+		close(*changed)
+	}
+	for _, hook := range m.hooks {
+		hook(from, to)
+	}
+	return true
+}
+
+// WaitFor blocks until the current state is state, or ctx is done, in which
+// case it returns the context's error.
+//
+// A state left right after being entered may be missed.
+func (m *StateMachine[S]) WaitFor(ctx context.Context, state S) error {
+	for {
+		// Getting the channel before checking the state, so that a transition
+		// happening in between closes it.
+		changed := m.changed.Load()
+		if changed == nil {
+			ch := make(chan struct{})
+			if !m.changed.CompareAndSwap(nil, &ch) {
+				continue
+			}
+			changed = &ch
+		}
+		if m.Current() == state {
+			return nil
+		}
+		select {
+		case <-*changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
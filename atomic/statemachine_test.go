@@ -0,0 +1,119 @@
+package atomic
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type jobState uint32
+
+const (
+	jobIdle jobState = iota
+	jobRunning
+	jobDone
+	jobFailed
+)
+
+func newJobStateMachine(hooks ...func(from, to jobState)) *StateMachine[jobState] {
+	return NewStateMachine(jobIdle, map[jobState][]jobState{
+		jobIdle:    {jobRunning},
+		jobRunning: {jobDone, jobFailed},
+		jobFailed:  {jobIdle},
+	}, hooks...)
+}
+
+func TestStateMachine(t *testing.T) {
+	var transitions [][2]jobState
+	m := newJobStateMachine(func(from, to jobState) {
+		transitions = append(transitions, [2]jobState{from, to})
+	})
+	require.Equal(t, jobIdle, m.Current())
+
+	// Not allowed.
+	require.False(t, m.Transition(jobIdle, jobDone))
+	// Not the current state.
+	require.False(t, m.Transition(jobRunning, jobDone))
+	require.Equal(t, jobIdle, m.Current())
+	require.Empty(t, transitions)
+
+	require.True(t, m.Transition(jobIdle, jobRunning))
+	require.True(t, m.Transition(jobRunning, jobFailed))
+	require.True(t, m.Transition(jobFailed, jobIdle))
+	require.Equal(t, jobIdle, m.Current())
+	require.Equal(t, [][2]jobState{
+		{jobIdle, jobRunning},
+		{jobRunning, jobFailed},
+		{jobFailed, jobIdle},
+	}, transitions)
+}
+
+func TestStateMachineSingleWinner(t *testing.T) {
+	m := newJobStateMachine()
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		wins int
+	)
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if m.Transition(jobIdle, jobRunning) {
+				mu.Lock()
+				wins++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 1, wins)
+	require.Equal(t, jobRunning, m.Current())
+}
+
+func TestStateMachineWaitFor(t *testing.T) {
+	m := newJobStateMachine()
+	require.NoError(t, m.WaitFor(context.Background(), jobIdle))
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, m.WaitFor(context.Background(), jobDone))
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	require.True(t, m.Transition(jobIdle, jobRunning))
+	time.Sleep(10 * time.Millisecond)
+	require.True(t, m.Transition(jobRunning, jobDone))
+	wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, m.WaitFor(ctx, jobIdle), context.DeadlineExceeded)
+}
+
+func TestStateMachineWaitForRacingTransitions(t *testing.T) {
+	for range 100 {
+		m := newJobStateMachine()
+		done := make(chan error)
+		go func() {
+			done <- m.WaitFor(context.Background(), jobRunning)
+		}()
+		require.True(t, m.Transition(jobIdle, jobRunning))
+		require.NoError(t, <-done)
+	}
+}
+
+func BenchmarkStateMachineTransition(b *testing.B) {
+	m := newJobStateMachine()
+	for b.Loop() {
+		m.Transition(jobIdle, jobRunning)
+		m.Transition(jobRunning, jobFailed)
+		m.Transition(jobFailed, jobIdle)
+	}
+}
@@ -0,0 +1,208 @@
+package atomic
+
+import (
+	"context"
+	"io"
+	"iter"
+	"sync"
+)
+
+// UnboundedChan is a close-safe channel whose buffer grows as needed, so that
+// send operations never block.
+//
+// Like a [CloseSafeChan], it can be closed by any goroutine while others are
+// sending : send operations started after the closing report it by returning
+// false, and receivers drain the queued values before observing the
+// [UnboundedChan] as closed.
+//
+// The queue is a ring buffer guarded by a mutex, it grows when full and
+// shrinks once mostly empty.
+type UnboundedChan[T any] struct {
+	mu sync.Mutex
+	// nonEmpty is signaled when a value is queued, and broadcast on close.
+	nonEmpty sync.Cond
+	queue    ringBuffer[T]
+	closed   bool
+	// highWaterMark and onHighWaterMark implement the soft limit set by
+	// [WithHighWaterMark], above tracks whether it is currently exceeded.
+	highWaterMark   int
+	onHighWaterMark func(backlog int)
+	above           bool
+}
+
+// UnboundedChanOption configures an [UnboundedChan], see [MakeUnboundedChan].
+type UnboundedChanOption func(*unboundedChanConfig)
+
+type unboundedChanConfig struct {
+	highWaterMark   int
+	onHighWaterMark func(backlog int)
+}
+
+// WithHighWaterMark sets a soft limit on the backlog of an [UnboundedChan] :
+// fn is called with the backlog each time it reaches n, coming from below.
+//
+// The limit is not enforced, fn is called by the sending goroutine and is
+// typically used to log or to throttle producers.
+func WithHighWaterMark(n int, fn func(backlog int)) UnboundedChanOption {
+	return func(c *unboundedChanConfig) {
+		c.highWaterMark = n
+		c.onHighWaterMark = fn
+	}
+}
+
+// MakeUnboundedChan initializes a new [UnboundedChan] instance.
+func MakeUnboundedChan[T any](opts ...UnboundedChanOption) *UnboundedChan[T] {
+	var cfg unboundedChanConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	c := &UnboundedChan[T]{
+		highWaterMark:   cfg.highWaterMark,
+		onHighWaterMark: cfg.onHighWaterMark,
+	}
+	c.nonEmpty.L = &c.mu
+	return c
+}
+
+// Close closes the [UnboundedChan] instance.
+//
+// It is safe to call it from concurrently running goroutines, and never
+// returns an error.
+func (c *UnboundedChan[T]) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	c.nonEmpty.Broadcast()
+	return nil
+}
+
+// Send queues a value in the [UnboundedChan] instance, without blocking.
+//
+// It returns true if the value was queued, false if the [UnboundedChan]
+// instance is closed.
+func (c *UnboundedChan[T]) Send(value T) bool {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return false
+	}
+	c.queue.push(value)
+	backlog := c.queue.Len()
+	crossed := c.onHighWaterMark != nil && !c.above && backlog >= c.highWaterMark
+	if crossed {
+		c.above = true
+	}
+	c.mu.Unlock()
+	c.nonEmpty.Signal()
+	if crossed {
+		c.onHighWaterMark(backlog)
+	}
+	return true
+}
+
+// Receive receives a value from the [UnboundedChan] instance, blocking until
+// one is queued.
+//
+// It returns the value, and a bool indicating if the [UnboundedChan] instance
+// is open (true) or closed and drained (false).
+func (c *UnboundedChan[T]) Receive() (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.queue.Len() == 0 && !c.closed {
+		c.nonEmpty.Wait()
+	}
+	return c.pop()
+}
+
+// ReceiveContext is like [UnboundedChan.Receive], but gives up when ctx is
+// done, returning the zero value, false and the context's error.
+//
+// Queued values are always received before giving up.
+func (c *UnboundedChan[T]) ReceiveContext(ctx context.Context) (T, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.queue.Len() == 0 && !c.closed {
+		stop := wakeOnDone(ctx, &c.nonEmpty)
+		defer stop()
+	}
+	for c.queue.Len() == 0 && !c.closed {
+		if err := ctx.Err(); err != nil {
+			return *new(T), false, err
+		}
+		c.nonEmpty.Wait()
+	}
+	value, ok := c.pop()
+	return value, ok, nil
+}
+
+// TryReceive receives a value from the [UnboundedChan] instance without
+// blocking.
+//
+// The ok result reports whether a value was received, and the open result
+// whether the [UnboundedChan] instance may still deliver values.
+func (c *UnboundedChan[T]) TryReceive() (value T, ok, open bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok = c.pop()
+	return value, ok, ok || !c.closed
+}
+
+// pop dequeues a value, the caller must hold the lock.
+func (c *UnboundedChan[T]) pop() (T, bool) {
+	value, ok := c.queue.pop()
+	if ok && c.above && c.queue.Len() < c.highWaterMark {
+		c.above = false
+	}
+	return value, ok
+}
+
+// Iter returns an iterator ranging on all the values queued in the
+// [UnboundedChan] instance, until it is closed and drained.
+func (c *UnboundedChan[T]) Iter() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			value, ok := c.Receive()
+			if !ok || !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// IterContext is like [UnboundedChan.Iter], but the iteration also stops once
+// ctx is done.
+func (c *UnboundedChan[T]) IterContext(ctx context.Context) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			value, ok, err := c.ReceiveContext(ctx)
+			if err != nil || !ok || !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// wakeOnDone broadcasts cond once ctx is done, so that goroutines waiting on
+// it can check ctx. The caller must hold cond.L, and call the returned stop
+// function before releasing it for good.
+func wakeOnDone(ctx context.Context, cond *sync.Cond) (stop func() bool) {
+	return context.AfterFunc(ctx, func() {
+		// Taking the lock, so that the broadcast can't slip between a waiter
+		// checking ctx and waiting.
+		cond.L.Lock()
+		defer cond.L.Unlock()
+		cond.Broadcast()
+	})
+}
+
+// Len returns the number of values queued (unread) in the [UnboundedChan]
+// instance.
+func (c *UnboundedChan[T]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.queue.Len()
+}
+
+var (
+	_ io.Closer = &UnboundedChan[any]{}
+)
@@ -0,0 +1,178 @@
+package atomic
+
+import (
+	"context"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestUnboundedChanSendReceive(t *testing.T) {
+	const nbSend = 10_000
+
+	ch := MakeUnboundedChan[int]()
+
+	for i := range nbSend {
+		require.True(t, ch.Send(i))
+	}
+	require.Equal(t, nbSend, ch.Len())
+	require.NoError(t, ch.Close())
+	require.False(t, ch.Send(nbSend))
+
+	var expected int
+	for v := range ch.Iter() {
+		require.Equal(t, expected, v)
+		expected++
+	}
+	require.Equal(t, nbSend, expected)
+
+	_, ok := ch.Receive()
+	require.False(t, ok)
+}
+
+func TestUnboundedChanTryReceive(t *testing.T) {
+	ch := MakeUnboundedChan[int]()
+
+	_, ok, open := ch.TryReceive()
+	require.False(t, ok)
+	require.True(t, open)
+
+	require.True(t, ch.Send(1))
+	require.NoError(t, ch.Close())
+
+	v, ok, open := ch.TryReceive()
+	require.True(t, ok)
+	require.True(t, open)
+	require.Equal(t, 1, v)
+
+	_, ok, open = ch.TryReceive()
+	require.False(t, ok)
+	require.False(t, open)
+}
+
+func TestUnboundedChanHighWaterMark(t *testing.T) {
+	var calls []int
+	ch := MakeUnboundedChan[int](WithHighWaterMark(3, func(backlog int) {
+		calls = append(calls, backlog)
+	}))
+
+	for i := range 5 {
+		require.True(t, ch.Send(i))
+	}
+	require.Equal(t, []int{3}, calls)
+
+	for range 3 {
+		_, ok := ch.Receive()
+		require.True(t, ok)
+	}
+	for i := range 2 {
+		require.True(t, ch.Send(i))
+	}
+	require.Equal(t, []int{3, 3}, calls)
+}
+
+func TestUnboundedChanReceiveContext(t *testing.T) {
+	ch := MakeUnboundedChan[int]()
+	require.True(t, ch.Send(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// Queued values first.
+	v, ok, err := ch.ReceiveContext(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+	_, ok, err = ch.ReceiveContext(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	require.False(t, ok)
+
+	// Waking up a blocked receiver.
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err = ch.ReceiveContext(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		ch.Send(2)
+		ch.Close()
+	}()
+	require.Equal(t, []int{2}, slices.Collect(ch.IterContext(context.Background())))
+	_, ok, err = ch.ReceiveContext(context.Background())
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestUnboundedChanConcurrentSendsReceivesCloses(t *testing.T) {
+	const (
+		nbReceivers = 8
+		nbSenders   = 32
+		nbClosers   = 8
+		nbSend      = 512
+	)
+
+	ch := MakeUnboundedChan[int]()
+
+	var sent, skipped, received atomic.Uint32
+	var receivers, others errgroup.Group
+
+	for range nbReceivers {
+		receivers.Go(func() error {
+			for range ch.Iter() {
+				received.Add(1)
+			}
+			return nil
+		})
+	}
+
+	start := make(chan struct{})
+	for range nbSenders {
+		others.Go(func() error {
+			<-start
+			for i := range nbSend {
+				if ch.Send(i) {
+					sent.Add(1)
+				} else {
+					skipped.Add(1)
+				}
+			}
+			return nil
+		})
+	}
+	for range nbClosers {
+		others.Go(func() error {
+			<-start
+			return ch.Close()
+		})
+	}
+	close(start)
+
+	require.NoError(t, others.Wait())
+	require.NoError(t, receivers.Wait())
+
+	require.Equal(t, sent.Load(), received.Load())
+	require.Equal(t, uint32(nbSenders*nbSend), sent.Load()+skipped.Load())
+	require.Zero(t, ch.Len())
+}
+
+func BenchmarkUnboundedChanSendReceiveSeq(b *testing.B) {
+	b.ReportAllocs()
+
+	ch := MakeUnboundedChan[struct{}]()
+	s := struct{}{}
+
+	b.Cleanup(func() {
+		require.NoError(b, ch.Close())
+	})
+
+	b.ResetTimer()
+
+	for range b.N {
+		_ = ch.Send(s)
+		_, _ = ch.Receive()
+	}
+}
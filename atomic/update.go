@@ -0,0 +1,125 @@
+package atomic
+
+import (
+	"context"
+	"math/rand/v2"
+	"runtime"
+	"time"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Update atomically replaces the value of v by fn(old), retrying with the new
+// current value until no other write races with it, and returns the replaced
+// and the stored values.
+//
+// fn may be called several times, and must not have side effects.
+func Update[T any](v Val[T], fn func(old T) T) (old, new T) {
+	for {
+		old = v.Load()
+		new = fn(old)
+		if v.CompareAndSwap(old, new) {
+			return old, new
+		}
+	}
+}
+
+// UpdateIf is like [Update], but fn can abort the update by returning false,
+// in which case v is left untouched and updated is false. The new result is
+// then the current value of v.
+func UpdateIf[T any](v Val[T], fn func(old T) (new T, ok bool)) (old, new T, updated bool) {
+	for {
+		old = v.Load()
+		new, ok := fn(old)
+		if !ok {
+			return old, old, false
+		}
+		if v.CompareAndSwap(old, new) {
+			return old, new, true
+		}
+	}
+}
+
+// Max atomically stores the maximum of v and val into v, and returns it.
+//
+// v isn't written if it already holds the maximum.
+func Max[T constraints.Integer](v Integer[T], val T) (new T) {
+	_, new, _ = UpdateIf(v, func(old T) (T, bool) {
+		return val, val > old
+	})
+	return new
+}
+
+// Min atomically stores the minimum of v and val into v, and returns it.
+//
+// v isn't written if it already holds the minimum.
+func Min[T constraints.Integer](v Integer[T], val T) (new T) {
+	_, new, _ = UpdateIf(v, func(old T) (T, bool) {
+		return val, val < old
+	})
+	return new
+}
+
+// AddClamp atomically adds delta to v, clamping the result to [lo, hi]
+// instead of wrapping around, and returns the new value.
+//
+// A value of v outside of [lo, hi] is brought back to the range as well. For
+// unsigned types, delta can only be positive.
+func AddClamp[T constraints.Integer](v Integer[T], delta, lo, hi T) (new T) {
+	_, new = Update(v, func(old T) T {
+		sum := old + delta
+		switch {
+		case delta > 0 && sum < old:
+			return hi
+		case delta < 0 && sum > old:
+			return lo
+		}
+		return min(max(sum, lo), hi)
+	})
+	return new
+}
+
+const (
+	// backoffSpins is the number of attempts yielding the processor before
+	// sleeping.
+	backoffSpins = 4
+	minBackoff   = time.Microsecond
+	maxBackoff   = time.Millisecond
+)
+
+// CompareAndSwapBackoff executes the compare-and-swap operation for v until it
+// succeeds, waiting between attempts with an exponential backoff, so that
+// goroutines contending on v don't keep on invalidating each other's cache.
+//
+// It returns nil once swapped, and the context's error if ctx is done first.
+// It suits operations waiting for v to hold old, such as acquiring a flag :
+//
+//	var locked atomic.Bool
+//	if err := CompareAndSwapBackoff(ctx, &locked, false, true); err != nil {
+//		return err
+//	}
+//	defer locked.Store(false)
+func CompareAndSwapBackoff[T any](ctx context.Context, v Val[T], old, new T) error {
+	var wait time.Duration
+	for attempt := 0; ; attempt++ {
+		if v.CompareAndSwap(old, new) {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if attempt < backoffSpins {
+			runtime.Gosched()
+			continue
+		}
+		wait = min(max(2*wait, minBackoff), maxBackoff)
+		// Jittering, so that goroutines failing together don't retry together.
+		t := time.NewTimer(wait/2 + rand.N(wait/2+1))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
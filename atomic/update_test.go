@@ -0,0 +1,146 @@
+package atomic
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdate(t *testing.T) {
+	var v atomic.Int64
+	v.Store(3)
+	old, new := Update[int64](&v, func(old int64) int64 { return old * 2 })
+	require.Equal(t, int64(3), old)
+	require.Equal(t, int64(6), new)
+	require.Equal(t, int64(6), v.Load())
+
+	var f Float64
+	_, got := Update[float64](&f, func(old float64) float64 { return old + 0.5 })
+	require.Equal(t, 0.5, got)
+}
+
+func TestUpdateIf(t *testing.T) {
+	var v Int[uint8]
+	v.Store(10)
+	old, new, ok := UpdateIf[uint8](&v, func(old uint8) (uint8, bool) { return old - 5, old >= 5 })
+	require.True(t, ok)
+	require.Equal(t, uint8(10), old)
+	require.Equal(t, uint8(5), new)
+
+	v.Store(3)
+	old, new, ok = UpdateIf[uint8](&v, func(old uint8) (uint8, bool) { return old - 5, old >= 5 })
+	require.False(t, ok)
+	require.Equal(t, uint8(3), old)
+	require.Equal(t, uint8(3), new)
+	require.Equal(t, uint8(3), v.Load())
+}
+
+func TestUpdateConcurrent(t *testing.T) {
+	var (
+		wg sync.WaitGroup
+		v  atomic.Uint32
+	)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				Update[uint32](&v, func(old uint32) uint32 { return old + 1 })
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, uint32(8000), v.Load())
+}
+
+func TestMaxMin(t *testing.T) {
+	var v atomic.Int32
+	require.Equal(t, int32(0), Max[int32](&v, -1))
+	require.Equal(t, int32(4), Max[int32](&v, 4))
+	require.Equal(t, int32(4), Max[int32](&v, 2))
+	require.Equal(t, int32(2), Min[int32](&v, 2))
+	require.Equal(t, int32(-7), Min[int32](&v, -7))
+	require.Equal(t, int32(-7), v.Load())
+
+	var (
+		wg     sync.WaitGroup
+		hi, lo Int[int]
+	)
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				Max[int](&hi, g*100+i)
+				Min[int](&lo, -(g*100 + i))
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 799, hi.Load())
+	require.Equal(t, -799, lo.Load())
+}
+
+func TestAddClamp(t *testing.T) {
+	var v Int[int8]
+	require.Equal(t, int8(10), AddClamp[int8](&v, 10, 0, 20))
+	require.Equal(t, int8(20), AddClamp[int8](&v, 15, 0, 20))
+	require.Equal(t, int8(0), AddClamp[int8](&v, -50, 0, 20))
+
+	// Without overflowing T.
+	v.Store(100)
+	require.Equal(t, int8(math.MaxInt8), AddClamp[int8](&v, 100, math.MinInt8, math.MaxInt8))
+	v.Store(-100)
+	require.Equal(t, int8(math.MinInt8), AddClamp[int8](&v, -100, math.MinInt8, math.MaxInt8))
+
+	var u atomic.Uint64
+	u.Store(2)
+	require.Equal(t, uint64(math.MaxUint64), AddClamp[uint64](&u, math.MaxUint64, 0, math.MaxUint64))
+
+	// Out of range values are brought back.
+	v.Store(50)
+	require.Equal(t, int8(20), AddClamp[int8](&v, 0, 0, 20))
+}
+
+func TestCompareAndSwapBackoff(t *testing.T) {
+	var locked atomic.Bool
+	ctx := context.Background()
+	require.NoError(t, CompareAndSwapBackoff[bool](ctx, &locked, false, true))
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, CompareAndSwapBackoff[bool](timeout, &locked, false, true), context.DeadlineExceeded)
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		locked.Store(false)
+	}()
+	require.NoError(t, CompareAndSwapBackoff[bool](ctx, &locked, false, true))
+	require.True(t, locked.Load())
+}
+
+func TestCompareAndSwapBackoffMutualExclusion(t *testing.T) {
+	var (
+		wg      sync.WaitGroup
+		locked  atomic.Bool
+		counter int
+	)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				require.NoError(t, CompareAndSwapBackoff[bool](context.Background(), &locked, false, true))
+				counter++
+				locked.Store(false)
+			}
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 800, counter)
+}
@@ -2,30 +2,125 @@ package atomic
 
 import "sync/atomic"
 
+// Value is an atomic value of type T.
+//
+// It stores a pointer to an immutable copy of the value, so that any T can be
+// stored without boxing it in an interface. Values are compared by
+// [Value.CompareAndSwap] with the == operator, for comparable types only, while
+// [Value.CompareAndSwapPointer] compares the stored copy by identity, and
+// [Value.CompareAndSwapFunc] by a given equality function.
+//
+// The zero value is ready to use and holds no value. A [Value] must not be
+// copied after first use.
 type Value[T any] struct {
-	underlying atomic.Value
-}
-
-func (v *Value[T]) CompareAndSwap(old T, new T) (swapped bool) {
-	return v.underlying.CompareAndSwap(old, new)
+	p atomic.Pointer[T]
 }
 
+// Load atomically loads the value stored in v.
+//
+// It returns the value, and a bool reporting whether a value was stored. The
+// zero value of T is returned if none was.
 func (v *Value[T]) Load() (val T, loaded bool) {
-	l := v.underlying.Load()
-	if l == nil {
+	p := v.p.Load()
+	if p == nil {
 		return val, false
 	}
-	return l.(T), true
+	return *p, true
+}
+
+// LoadPointer atomically loads the stored copy of the value, nil if no value
+// was stored.
+//
+// It is meant to be given to [Value.CompareAndSwapPointer], and must not be
+// modified.
+func (v *Value[T]) LoadPointer() *T {
+	return v.p.Load()
 }
 
+// Store atomically stores val into v.
 func (v *Value[T]) Store(val T) {
-	v.underlying.Store(val)
+	v.p.Store(&val)
 }
 
+// Swap atomically stores new into v and returns the previous value.
+//
+// The bool result reports whether a value was stored before.
 func (v *Value[T]) Swap(new T) (old T, swapped bool) {
-	prev := v.underlying.Swap(new)
-	if prev == nil {
+	p := v.p.Swap(&new)
+	if p == nil {
 		return old, false
 	}
-	return prev.(T), true
+	return *p, true
+}
+
+// CompareAndSwap atomically stores new into v if its value is equal to old,
+// as reported by the == operator.
+//
+// A [Value] holding no value is compared as the zero value of T. It panics if
+// the values aren't comparable (slices, maps, funcs, or interfaces holding
+// them), use [Value.CompareAndSwapFunc] or [Value.CompareAndSwapPointer] for
+// such types.
+func (v *Value[T]) CompareAndSwap(old, new T) (swapped bool) {
+	return v.CompareAndSwapFunc(old, new, func(a, b T) bool {
+		return any(a) == any(b)
+	})
+}
+
+// CompareAndSwapPointer atomically stores new into v if its stored copy is
+// still old, as returned by [Value.LoadPointer].
+//
+// A nil old matches a [Value] holding no value.
+func (v *Value[T]) CompareAndSwapPointer(old *T, new T) (swapped bool) {
+	return v.p.CompareAndSwap(old, &new)
+}
+
+// CompareAndSwapFunc atomically stores new into v if equal reports that its
+// value is equal to old.
+//
+// A [Value] holding no value is compared as the zero value of T. The equal
+// function may be called several times when racing with other writes.
+func (v *Value[T]) CompareAndSwapFunc(old, new T, equal func(a, b T) bool) (swapped bool) {
+	for {
+		p := v.p.Load()
+		var cur T
+		if p != nil {
+			cur = *p
+		}
+		if !equal(cur, old) {
+			return false
+		}
+		if v.p.CompareAndSwap(p, &new) {
+			return true
+		}
+	}
+}
+
+// Val returns a view of v implementing [Val], comparing values with equal.
+//
+// A [Value] holding no value is seen as holding the zero value of T.
+func (v *Value[T]) Val(equal func(a, b T) bool) Val[T] {
+	return valueVal[T]{v: v, equal: equal}
+}
+
+type valueVal[T any] struct {
+	v     *Value[T]
+	equal func(a, b T) bool
+}
+
+func (v valueVal[T]) Load() T {
+	val, _ := v.v.Load()
+	return val
+}
+
+func (v valueVal[T]) Store(val T) {
+	v.v.Store(val)
+}
+
+func (v valueVal[T]) Swap(new T) (old T) {
+	old, _ = v.v.Swap(new)
+	return old
+}
+
+func (v valueVal[T]) CompareAndSwap(old, new T) (swapped bool) {
+	return v.v.CompareAndSwapFunc(old, new, v.equal)
 }
@@ -0,0 +1,146 @@
+package atomic
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type config struct {
+	name  string
+	peers []string
+}
+
+func equalConfig(a, b config) bool {
+	return a.name == b.name && slices.Equal(a.peers, b.peers)
+}
+
+func TestValue(t *testing.T) {
+	var v Value[config]
+	got, ok := v.Load()
+	require.False(t, ok)
+	require.Zero(t, got)
+	require.Nil(t, v.LoadPointer())
+
+	old, ok := v.Swap(config{name: "a"})
+	require.False(t, ok)
+	require.Zero(t, old)
+
+	v.Store(config{name: "b", peers: []string{"x"}})
+	got, ok = v.Load()
+	require.True(t, ok)
+	require.Equal(t, config{name: "b", peers: []string{"x"}}, got)
+
+	old, ok = v.Swap(config{name: "c"})
+	require.True(t, ok)
+	require.Equal(t, "b", old.name)
+}
+
+func TestValueCompareAndSwapPointer(t *testing.T) {
+	var v Value[[]int]
+	require.True(t, v.CompareAndSwapPointer(nil, []int{1}))
+	require.False(t, v.CompareAndSwapPointer(nil, []int{2}))
+
+	p := v.LoadPointer()
+	require.Equal(t, []int{1}, *p)
+	// Equal values stored by another write don't match.
+	v.Store([]int{1})
+	require.False(t, v.CompareAndSwapPointer(p, []int{2}))
+
+	p = v.LoadPointer()
+	require.True(t, v.CompareAndSwapPointer(p, []int{2}))
+	got, _ := v.Load()
+	require.Equal(t, []int{2}, got)
+}
+
+func TestValueCompareAndSwapFunc(t *testing.T) {
+	var v Value[config]
+	// No value is compared as the zero value.
+	require.False(t, v.CompareAndSwapFunc(config{name: "a"}, config{name: "b"}, equalConfig))
+	require.True(t, v.CompareAndSwapFunc(config{}, config{name: "a", peers: []string{"x"}}, equalConfig))
+
+	require.False(t, v.CompareAndSwapFunc(config{name: "a"}, config{name: "b"}, equalConfig))
+	require.True(t, v.CompareAndSwapFunc(config{name: "a", peers: []string{"x"}}, config{name: "b"}, equalConfig))
+	got, _ := v.Load()
+	require.Equal(t, config{name: "b"}, got)
+}
+
+func TestValueCompareAndSwap(t *testing.T) {
+	var v Value[int]
+	require.False(t, v.CompareAndSwap(1, 2))
+	require.True(t, v.CompareAndSwap(0, 1))
+	require.True(t, v.CompareAndSwap(1, 2))
+	got, _ := v.Load()
+	require.Equal(t, 2, got)
+
+	var e Value[error]
+	require.True(t, e.CompareAndSwap(nil, errors.New("boom")))
+
+	var s Value[[]int]
+	s.Store([]int{1})
+	require.Panics(t, func() { s.CompareAndSwap(nil, nil) })
+}
+
+func TestValueVal(t *testing.T) {
+	var v Value[config]
+	var val Val[config] = v.Val(equalConfig)
+	require.Zero(t, val.Load())
+	require.Zero(t, val.Swap(config{name: "a"}))
+	require.True(t, val.CompareAndSwap(config{name: "a"}, config{name: "b"}))
+	require.False(t, val.CompareAndSwap(config{name: "a"}, config{name: "c"}))
+	val.Store(config{name: "d"})
+	got, ok := v.Load()
+	require.True(t, ok)
+	require.Equal(t, "d", got.name)
+}
+
+func TestValueConcurrent(t *testing.T) {
+	var (
+		wg sync.WaitGroup
+		v  Value[[]int]
+	)
+	v.Store(nil)
+	// Appending through CAS loops, each append must survive.
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				for {
+					p := v.LoadPointer()
+					next := append(slices.Clone(*p), g*100+i)
+					if v.CompareAndSwapPointer(p, next) {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	got, _ := v.Load()
+	slices.Sort(got)
+	require.Len(t, got, 800)
+	for i, n := range got {
+		require.Equal(t, i, n)
+	}
+}
+
+func BenchmarkValueLoad(b *testing.B) {
+	var v Value[config]
+	v.Store(config{name: "a"})
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			v.Load()
+		}
+	})
+}
+
+func BenchmarkValueStore(b *testing.B) {
+	var v Value[config]
+	for b.Loop() {
+		v.Store(config{name: "a"})
+	}
+}
@@ -0,0 +1,168 @@
+package atomic
+
+import (
+	"context"
+	"io"
+	"iter"
+	"time"
+)
+
+// Sender is the sending end of a close-safe channel, like a chan<- T.
+//
+// Send reports whether the value was accepted, false meaning the channel is
+// closed, and Close can be called concurrently with Send.
+type Sender[T any] interface {
+	Send(value T) bool
+	io.Closer
+}
+
+// Receiver is the receiving end of a close-safe channel, like a <-chan T.
+//
+// Receive reports whether the channel is still open, false meaning it is
+// closed and drained, and Iter ranges on the values until then.
+type Receiver[T any] interface {
+	Receive() (T, bool)
+	Iter() iter.Seq[T]
+}
+
+// contextReceiver is a [Receiver] whose receive operations can be canceled,
+// exposed by the receiving views of the cond-based channels.
+type contextReceiver[T any] interface {
+	Receiver[T]
+	ReceiveContext(ctx context.Context) (T, bool, error)
+	TryReceive() (T, bool, bool)
+	IterContext(ctx context.Context) iter.Seq[T]
+}
+
+// Sender returns a view of the [CloseSafeChan] instance that can only send and
+// close.
+func (c *CloseSafeChan[T]) Sender() Sender[T] {
+	return chanSender[T]{c: c}
+}
+
+// Receiver returns a view of the [CloseSafeChan] instance that can only
+// receive.
+func (c *CloseSafeChan[T]) Receiver() Receiver[T] {
+	return chanReceiver[T]{c: c}
+}
+
+// chanSender restricts a [CloseSafeChan] to its sending methods.
+type chanSender[T any] struct {
+	c *CloseSafeChan[T]
+}
+
+func (s chanSender[T]) Send(value T) bool {
+	return s.c.Send(value)
+}
+
+func (s chanSender[T]) SendContext(ctx context.Context, value T) error {
+	return s.c.SendContext(ctx, value)
+}
+
+func (s chanSender[T]) SendTimeout(value T, d time.Duration) error {
+	return s.c.SendTimeout(value, d)
+}
+
+func (s chanSender[T]) TrySend(value T) bool {
+	return s.c.TrySend(value)
+}
+
+func (s chanSender[T]) Close() error {
+	return s.c.Close()
+}
+
+func (s chanSender[T]) CloseWithError(err error) error {
+	return s.c.CloseWithError(err)
+}
+
+// chanReceiver restricts a [CloseSafeChan] to its receiving methods.
+type chanReceiver[T any] struct {
+	c *CloseSafeChan[T]
+}
+
+func (r chanReceiver[T]) Receive() (T, bool) {
+	return r.c.Receive()
+}
+
+func (r chanReceiver[T]) ReceiveContext(ctx context.Context) (T, bool, error) {
+	return r.c.ReceiveContext(ctx)
+}
+
+func (r chanReceiver[T]) ReceiveTimeout(d time.Duration) (T, bool, error) {
+	return r.c.ReceiveTimeout(d)
+}
+
+func (r chanReceiver[T]) TryReceive() (T, bool, bool) {
+	return r.c.TryReceive()
+}
+
+func (r chanReceiver[T]) Iter() iter.Seq[T] {
+	return r.c.Iter()
+}
+
+func (r chanReceiver[T]) IterContext(ctx context.Context) iter.Seq[T] {
+	return r.c.IterContext(ctx)
+}
+
+func (r chanReceiver[T]) Done() <-chan struct{} {
+	return r.c.Done()
+}
+
+func (r chanReceiver[T]) Err() error {
+	return r.c.Err()
+}
+
+func (r chanReceiver[T]) Len() int {
+	return r.c.Len()
+}
+
+// Sender returns a view of the [UnboundedChan] instance that can only send and
+// close.
+func (c *UnboundedChan[T]) Sender() Sender[T] {
+	return struct{ Sender[T] }{c}
+}
+
+// Receiver returns a view of the [UnboundedChan] instance that can only
+// receive.
+func (c *UnboundedChan[T]) Receiver() Receiver[T] {
+	return struct{ contextReceiver[T] }{c}
+}
+
+// Lane returns a view of the [PriorityChan] instance that sends to the given
+// lane, and closes the whole [PriorityChan]. It panics if priority isn't a
+// valid lane.
+func (c *PriorityChan[T]) Lane(priority int) Sender[T] {
+	_ = c.lanes[priority]
+	return priorityLaneSender[T]{c: c, priority: priority}
+}
+
+// Receiver returns a view of the [PriorityChan] instance that can only
+// receive.
+func (c *PriorityChan[T]) Receiver() Receiver[T] {
+	return struct{ contextReceiver[T] }{c}
+}
+
+type priorityLaneSender[T any] struct {
+	c        *PriorityChan[T]
+	priority int
+}
+
+func (s priorityLaneSender[T]) Send(value T) bool {
+	return s.c.Send(s.priority, value)
+}
+
+func (s priorityLaneSender[T]) Close() error {
+	return s.c.Close()
+}
+
+var (
+	_ Sender[any]   = &CloseSafeChan[any]{}
+	_ Receiver[any] = &CloseSafeChan[any]{}
+	_ Sender[any]   = &UnboundedChan[any]{}
+	_ Receiver[any] = &UnboundedChan[any]{}
+	_ Receiver[any] = &PriorityChan[any]{}
+	_ Receiver[any] = &Subscription[any]{}
+
+	_ contextReceiver[any] = &UnboundedChan[any]{}
+	_ contextReceiver[any] = &PriorityChan[any]{}
+)
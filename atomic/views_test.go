@@ -0,0 +1,71 @@
+package atomic
+
+import (
+	"context"
+	"iter"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseSafeChanViews(t *testing.T) {
+	ch := MakeCloseSafeChan[int](2)
+
+	s, r := ch.Sender(), ch.Receiver()
+	_, isChan := s.(*CloseSafeChan[int])
+	require.False(t, isChan)
+	_, isChan = r.(*CloseSafeChan[int])
+	require.False(t, isChan)
+
+	require.True(t, s.Send(1))
+	require.NoError(t, s.(interface {
+		SendContext(context.Context, int) error
+	}).SendContext(context.Background(), 2))
+	require.NoError(t, s.Close())
+	require.False(t, s.Send(3))
+
+	v, ok := r.Receive()
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+	for v := range r.Iter() {
+		require.Equal(t, 2, v)
+	}
+	_, ok = r.Receive()
+	require.False(t, ok)
+}
+
+func TestPriorityChanLane(t *testing.T) {
+	ch := MakePriorityChan[string](2, 2)
+	low, high := ch.Lane(1), ch.Lane(0)
+
+	require.True(t, low.Send("low"))
+	require.True(t, high.Send("high"))
+	require.NoError(t, low.Close())
+	require.False(t, high.Send("closed"))
+
+	var got []string
+	for v := range ch.Receiver().Iter() {
+		got = append(got, v)
+	}
+	require.Equal(t, []string{"high", "low"}, got)
+
+	require.Panics(t, func() { ch.Lane(2) })
+}
+
+func TestCondChanViewsCancelable(t *testing.T) {
+	for _, r := range []Receiver[int]{
+		MakeUnboundedChan[int]().Receiver(),
+		MakePriorityChan[int](2, 1).Receiver(),
+	} {
+		_, ok := r.(interface {
+			IterContext(context.Context) iter.Seq[int]
+		})
+		require.True(t, ok)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, _, err := r.(interface {
+			ReceiveContext(context.Context) (int, bool, error)
+		}).ReceiveContext(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+	}
+}
@@ -0,0 +1,340 @@
+// Package peer implements a cache shared by a static set of processes, in the
+// style of groupcache.
+//
+// Keys are assigned to an owner through a consistent hashing [Ring]. The owner
+// loads missing keys with the [Getter] and keeps them in its main cache, while
+// the other peers fetch them from the owner over HTTP and keep the popular
+// ones in a local hot cache. Concurrent loads of the same key are deduplicated
+// on every node.
+package peer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/wazazaby/gs/cache"
+	"github.com/wazazaby/gs/singleflight"
+)
+
+// DefaultBasePath is the HTTP path prefix under which groups are served.
+const DefaultBasePath = "/_gs/peer/"
+
+// Getter loads the value of a key owned by the local node.
+type Getter[V any] func(ctx context.Context, key string) (V, error)
+
+// Option configures a [Group].
+type Option func(*options)
+
+type options struct {
+	replicas int
+	mainSize int
+	hotSize  int
+	basePath string
+	client   *http.Client
+}
+
+// WithReplicas sets the number of virtual nodes of each peer on the [Ring].
+func WithReplicas(n int) Option {
+	return func(o *options) { o.replicas = n }
+}
+
+// WithMainCacheSize sets the maximum number of owned keys kept in memory.
+func WithMainCacheSize(n int) Option {
+	return func(o *options) { o.mainSize = n }
+}
+
+// WithHotCacheSize sets the maximum number of keys owned by other peers kept
+// in memory.
+func WithHotCacheSize(n int) Option {
+	return func(o *options) { o.hotSize = n }
+}
+
+// WithBasePath sets the HTTP path prefix shared by all peers. It defaults to
+// [DefaultBasePath].
+func WithBasePath(p string) Option {
+	return func(o *options) { o.basePath = p }
+}
+
+// WithHTTPClient sets the client used to fetch values from other peers. It
+// defaults to [http.DefaultClient].
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *options) { o.client = c }
+}
+
+// Stats holds the counters of a [Group].
+type Stats struct {
+	// Gets is the number of [Group.Get] calls.
+	Gets uint64
+	// MainHits is the number of Gets served by the main cache.
+	MainHits uint64
+	// HotHits is the number of Gets served by the hot cache.
+	HotHits uint64
+	// PeerLoads is the number of values fetched from another peer.
+	PeerLoads uint64
+	// PeerErrors is the number of failed fetches from another peer.
+	PeerErrors uint64
+	// LocalLoads is the number of [Getter] calls.
+	LocalLoads uint64
+	// ServerRequests is the number of requests served to other peers.
+	ServerRequests uint64
+}
+
+// Group is a named keyspace shared by a set of peers.
+//
+// Every peer runs a [Group] with the same name, the same list of peers and the
+// same options, and serves it over HTTP (a [Group] is an [http.Handler]).
+// Values travel between peers JSON-encoded.
+type Group[V any] struct {
+	name     string
+	self     string
+	prefix   string
+	ring     *Ring
+	getter   Getter[V]
+	client   *http.Client
+	loads    singleflight.Group[V]
+	main     lruCache[V]
+	hot      lruCache[V]
+	gets     atomic.Uint64
+	mainHits atomic.Uint64
+	hotHits  atomic.Uint64
+	peerOK   atomic.Uint64
+	peerErr  atomic.Uint64
+	local    atomic.Uint64
+	served   atomic.Uint64
+}
+
+// NewGroup creates a [Group] named name running on the peer self.
+//
+// Peers are identified by their base URL (e.g. "http://10.0.0.1:8080"), and
+// self must be one of peers, NewGroup panics otherwise.
+func NewGroup[V any](name, self string, peers []string, getter Getter[V], opts ...Option) *Group[V] {
+	if !slices.Contains(peers, self) {
+		panic(fmt.Sprintf("peer: self %q is not one of the peers", self))
+	}
+	o := options{
+		replicas: 50,
+		mainSize: 1024,
+		hotSize:  128,
+		basePath: DefaultBasePath,
+		client:   http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Group[V]{
+		name:   name,
+		self:   self,
+		prefix: o.basePath + url.PathEscape(name) + "/",
+		ring:   NewRing(o.replicas, peers...),
+		getter: getter,
+		client: o.client,
+		main:   lruCache[V]{max: o.mainSize},
+		hot:    lruCache[V]{max: o.hotSize},
+	}
+}
+
+// Name returns the name of the [Group].
+func (g *Group[V]) Name() string {
+	return g.name
+}
+
+// Path returns the HTTP path prefix served by the [Group], to register it on a
+// [http.ServeMux].
+func (g *Group[V]) Path() string {
+	return g.prefix
+}
+
+// Get returns the value of key, loading it from its owner if needed.
+//
+// If the owner can't be reached, the value is loaded locally.
+func (g *Group[V]) Get(ctx context.Context, key string) (V, error) {
+	g.gets.Add(1)
+	if v, ok := g.cached(key); ok {
+		return v, nil
+	}
+	v, err, _ := g.loads.Do(key, func() (V, error) {
+		if v, ok := g.cached(key); ok {
+			return v, nil
+		}
+		if owner := g.ring.Get(key); owner != "" && owner != g.self {
+			v, err := g.fetch(ctx, owner, key)
+			if err == nil {
+				g.peerOK.Add(1)
+				g.hot.add(key, v)
+				return v, nil
+			}
+			g.peerErr.Add(1)
+		}
+		return g.load(ctx, key)
+	})
+	return v, err
+}
+
+// Remove evicts key from the local caches. Other peers are left untouched.
+func (g *Group[V]) Remove(key string) {
+	g.main.remove(key)
+	g.hot.remove(key)
+}
+
+// Stats returns a snapshot of the counters of the [Group].
+func (g *Group[V]) Stats() Stats {
+	return Stats{
+		Gets:           g.gets.Load(),
+		MainHits:       g.mainHits.Load(),
+		HotHits:        g.hotHits.Load(),
+		PeerLoads:      g.peerOK.Load(),
+		PeerErrors:     g.peerErr.Load(),
+		LocalLoads:     g.local.Load(),
+		ServerRequests: g.served.Load(),
+	}
+}
+
+// ServeHTTP serves the values owned by this peer to the other peers.
+func (g *Group[V]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	encoded, ok := strings.CutPrefix(r.URL.Path, g.prefix)
+	if !ok || encoded == "" {
+		http.NotFound(w, r)
+		return
+	}
+	key, err := decodeKey(encoded)
+	if err != nil {
+		http.Error(w, "invalid key", http.StatusBadRequest)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	g.served.Add(1)
+	v, ok := g.main.get(key)
+	if ok {
+		g.mainHits.Add(1)
+	} else {
+		var err error
+		v, err, _ = g.loads.Do(key, func() (V, error) {
+			if v, ok := g.main.get(key); ok {
+				return v, nil
+			}
+			return g.load(r.Context(), key)
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (g *Group[V]) cached(key string) (V, bool) {
+	if v, ok := g.main.get(key); ok {
+		g.mainHits.Add(1)
+		return v, true
+	}
+	if v, ok := g.hot.get(key); ok {
+		g.hotHits.Add(1)
+		return v, true
+	}
+	return *new(V), false
+}
+
+func (g *Group[V]) load(ctx context.Context, key string) (V, error) {
+	g.local.Add(1)
+	v, err := g.getter(ctx, key)
+	if err != nil {
+		return v, err
+	}
+	g.main.add(key, v)
+	return v, nil
+}
+
+func (g *Group[V]) fetch(ctx context.Context, owner, key string) (V, error) {
+	var v V
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, owner+g.prefix+encodeKey(key), nil)
+	if err != nil {
+		return v, err
+	}
+	res, err := g.client.Do(req)
+	if err != nil {
+		return v, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return v, fmt.Errorf("peer: %s returned %s: %s", owner, res.Status, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(res.Body).Decode(&v); err != nil {
+		return v, fmt.Errorf("peer: decoding value from %s: %w", owner, err)
+	}
+	return v, nil
+}
+
+// encodeKey encodes key for a request path. Keys are base64url-encoded, so
+// that keys such as ".." don't produce paths cleaned and redirected by an
+// [http.ServeMux].
+func encodeKey(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+func decodeKey(encoded string) (string, error) {
+	key, err := base64.RawURLEncoding.DecodeString(encoded)
+	return string(key), err
+}
+
+// lruCache is a concurrency-safe [cache.LRU] evicting its least recently used
+// entries once it holds more than max entries.
+type lruCache[V any] struct {
+	mu  sync.Mutex
+	lru cache.LRU[string, entry[V]]
+	max int
+}
+
+// entry keeps the key next to the value, as [cache.LRU] only hands out values.
+type entry[V any] struct {
+	key   string
+	value V
+}
+
+func (c *lruCache[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// MakeMRU is a no-op for missing keys, the MRU entry is then another key.
+	c.lru.MakeMRU(key)
+	if e, ok := c.lru.GetMRU(); ok && e.key == key {
+		return e.value, true
+	}
+	return *new(V), false
+}
+
+func (c *lruCache[V]) add(key string, value V) {
+	if c.max <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Upsert(key, entry[V]{key: key, value: value})
+	for c.lru.Len() > c.max {
+		e, _ := c.lru.GetLRU()
+		c.lru.Remove(e.key)
+	}
+}
+
+func (c *lruCache[V]) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Remove(key)
+}
+
+var (
+	_ http.Handler = &Group[any]{}
+)
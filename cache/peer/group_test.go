@@ -0,0 +1,222 @@
+package peer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type cluster struct {
+	groups []*Group[string]
+	loads  atomic.Int64
+}
+
+func newCluster(t *testing.T, size int, getter Getter[string], opts ...Option) *cluster {
+	t.Helper()
+
+	c := &cluster{}
+	handlers := make([]http.Handler, size)
+	peers := make([]string, size)
+	for i := range size {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlers[i].ServeHTTP(w, r)
+		}))
+		t.Cleanup(srv.Close)
+		peers[i] = srv.URL
+	}
+	counted := func(ctx context.Context, key string) (string, error) {
+		c.loads.Add(1)
+		return getter(ctx, key)
+	}
+	for i := range size {
+		g := NewGroup("test", peers[i], peers, counted, opts...)
+		mux := http.NewServeMux()
+		mux.Handle(g.Path(), g)
+		handlers[i] = mux
+		c.groups = append(c.groups, g)
+	}
+	return c
+}
+
+func echo(_ context.Context, key string) (string, error) {
+	return "value:" + key, nil
+}
+
+func TestGroupGet(t *testing.T) {
+	c := newCluster(t, 3, echo)
+	ctx := context.Background()
+
+	for i := range 100 {
+		key := "key/" + strconv.Itoa(i)
+		for _, g := range c.groups {
+			got, err := g.Get(ctx, key)
+			if err != nil {
+				t.Fatalf("Get(%q) error = %v", key, err)
+			}
+			if want := "value:" + key; got != want {
+				t.Fatalf("Get(%q) = %q; want %q", key, got, want)
+			}
+		}
+	}
+
+	if got := c.loads.Load(); got != 100 {
+		t.Fatalf("getter called %d times; want 100 (once per key)", got)
+	}
+
+	var peerLoads uint64
+	for _, g := range c.groups {
+		peerLoads += g.Stats().PeerLoads
+	}
+	if peerLoads != 200 {
+		t.Fatalf("peer loads = %d; want 200 (each key fetched by the 2 non-owners)", peerLoads)
+	}
+}
+
+func TestGroupUncleanKeys(t *testing.T) {
+	c := newCluster(t, 2, echo)
+	ctx := context.Background()
+
+	keys := []string{"..", ".", "a/.", "a/../b", "//", "a b?c#d%", "é"}
+	for _, key := range keys {
+		for _, g := range c.groups {
+			got, err := g.Get(ctx, key)
+			if err != nil {
+				t.Fatalf("Get(%q) error = %v", key, err)
+			}
+			if want := "value:" + key; got != want {
+				t.Fatalf("Get(%q) = %q; want %q", key, got, want)
+			}
+		}
+	}
+	for _, g := range c.groups {
+		if s := g.Stats(); s.PeerErrors != 0 {
+			t.Fatalf("peer errors = %d; want 0", s.PeerErrors)
+		}
+	}
+	if got := c.loads.Load(); got != int64(len(keys)) {
+		t.Fatalf("getter called %d times; want %d (once per key)", got, len(keys))
+	}
+}
+
+func TestGroupHotCache(t *testing.T) {
+	c := newCluster(t, 2, echo)
+	ctx := context.Background()
+
+	var key string
+	for i := 0; ; i++ {
+		key = strconv.Itoa(i)
+		if c.groups[0].ring.Get(key) != c.groups[0].self {
+			break
+		}
+	}
+
+	for range 10 {
+		if _, err := c.groups[0].Get(ctx, key); err != nil {
+			t.Fatalf("Get(%q) error = %v", key, err)
+		}
+	}
+
+	s := c.groups[0].Stats()
+	if s.PeerLoads != 1 || s.HotHits != 9 {
+		t.Fatalf("peer loads = %d, hot hits = %d; want 1, 9", s.PeerLoads, s.HotHits)
+	}
+	if got := c.groups[1].Stats().ServerRequests; got != 1 {
+		t.Fatalf("owner served %d requests; want 1", got)
+	}
+}
+
+func TestGroupDeduplicatesLoads(t *testing.T) {
+	release := make(chan struct{})
+	c := newCluster(t, 3, func(ctx context.Context, key string) (string, error) {
+		<-release
+		return key, nil
+	})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for _, g := range c.groups {
+		for range 10 {
+			wg.Go(func() {
+				if _, err := g.Get(ctx, "shared"); err != nil {
+					t.Errorf("Get error = %v", err)
+				}
+			})
+		}
+	}
+	close(release)
+	wg.Wait()
+
+	if got := c.loads.Load(); got != 1 {
+		t.Fatalf("getter called %d times; want 1", got)
+	}
+}
+
+func TestGroupGetterError(t *testing.T) {
+	errBoom := errors.New("boom")
+	c := newCluster(t, 2, func(context.Context, string) (string, error) {
+		return "", errBoom
+	})
+
+	for _, g := range c.groups {
+		if _, err := g.Get(context.Background(), "foo"); err == nil {
+			t.Fatalf("Get error = nil; want an error")
+		}
+	}
+}
+
+func TestGroupPeerDown(t *testing.T) {
+	peers := []string{"http://127.0.0.1:1", "self"}
+	g := NewGroup("test", "self", peers, echo)
+
+	var key string
+	for i := 0; ; i++ {
+		key = strconv.Itoa(i)
+		if g.ring.Get(key) != "self" {
+			break
+		}
+	}
+
+	got, err := g.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%q) error = %v", key, err)
+	}
+	if want := "value:" + key; got != want {
+		t.Fatalf("Get(%q) = %q; want %q", key, got, want)
+	}
+	if s := g.Stats(); s.PeerErrors != 1 || s.LocalLoads != 1 {
+		t.Fatalf("peer errors = %d, local loads = %d; want 1, 1", s.PeerErrors, s.LocalLoads)
+	}
+}
+
+func TestNewGroupSelfNotAPeer(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("NewGroup with self not in peers didn't panic")
+		}
+	}()
+	NewGroup("test", "http://c", []string{"http://a", "http://b"}, echo)
+}
+
+func TestLRUCacheEviction(t *testing.T) {
+	c := lruCache[int]{max: 2}
+	c.add("a", 1)
+	c.add("b", 2)
+	if _, ok := c.get("a"); !ok {
+		t.Fatalf("get(%q) ok = false; want true", "a")
+	}
+	c.add("c", 3)
+
+	if _, ok := c.get("b"); ok {
+		t.Fatalf("get(%q) ok = true after eviction; want false", "b")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.get(key); !ok {
+			t.Fatalf("get(%q) ok = false; want true", key)
+		}
+	}
+}
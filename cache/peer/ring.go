@@ -0,0 +1,54 @@
+package peer
+
+import (
+	"hash/crc32"
+	"slices"
+	"strconv"
+)
+
+// Ring assigns keys to peers using consistent hashing.
+//
+// Every peer is placed on the ring several times (its replicas, or virtual
+// nodes) so that keys spread evenly, and adding or removing a peer only moves
+// the keys that hashed next to it. A [Ring] is immutable once built and is safe
+// for concurrent use.
+type Ring struct {
+	hashes []uint32
+	owners map[uint32]string
+}
+
+// NewRing builds a [Ring] placing each peer replicas times on the ring.
+//
+// A replicas value lower than 1 is treated as 1.
+func NewRing(replicas int, peers ...string) *Ring {
+	replicas = max(replicas, 1)
+	r := &Ring{
+		hashes: make([]uint32, 0, replicas*len(peers)),
+		owners: make(map[uint32]string, replicas*len(peers)),
+	}
+	for _, p := range peers {
+		for i := range replicas {
+			h := crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + p))
+			if _, ok := r.owners[h]; ok {
+				continue
+			}
+			r.hashes = append(r.hashes, h)
+			r.owners[h] = p
+		}
+	}
+	slices.Sort(r.hashes)
+	return r
+}
+
+// Get returns the peer owning key, or an empty string if the ring is empty.
+func (r *Ring) Get(key string) string {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+	h := crc32.ChecksumIEEE([]byte(key))
+	i, _ := slices.BinarySearch(r.hashes, h)
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.owners[r.hashes[i]]
+}
@@ -0,0 +1,46 @@
+package peer
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestRingEmpty(t *testing.T) {
+	r := NewRing(10)
+	if got := r.Get("foo"); got != "" {
+		t.Fatalf("Get on empty ring = %q; want %q", got, "")
+	}
+}
+
+func TestRingConsistent(t *testing.T) {
+	peers := []string{"a", "b", "c"}
+	r1 := NewRing(50, peers...)
+	r2 := NewRing(50, "c", "a", "b")
+
+	counts := make(map[string]int)
+	for i := range 1000 {
+		key := strconv.Itoa(i)
+		got := r1.Get(key)
+		if other := r2.Get(key); got != other {
+			t.Fatalf("Get(%q) = %q and %q depending on peer order", key, got, other)
+		}
+		counts[got]++
+	}
+	for _, p := range peers {
+		if counts[p] == 0 {
+			t.Fatalf("peer %q owns no key out of 1000", p)
+		}
+	}
+}
+
+func TestRingRemovePeer(t *testing.T) {
+	r := NewRing(50, "a", "b", "c")
+	shrunk := NewRing(50, "a", "b")
+
+	for i := range 1000 {
+		key := strconv.Itoa(i)
+		if owner := r.Get(key); owner != "c" && shrunk.Get(key) != owner {
+			t.Fatalf("Get(%q) moved from %q to %q after removing an unrelated peer", key, owner, shrunk.Get(key))
+		}
+	}
+}
@@ -0,0 +1,377 @@
+// Package pipeline provides typed stages connecting close-safe channels,
+// replacing the goroutine boilerplate of channel pipelines.
+//
+// Every stage reads its input, any [atomic.Receiver], in a goroutine of its
+// own and writes to a new [atomic.CloseSafeChan] it returns, and stops when :
+//   - its input is closed and drained, in which case it closes its output with
+//     the input's cause as cause (see [atomic.CloseSafeChan.Err])
+//   - its context is done, in which case it closes its output with the
+//     context's error as cause
+//   - its output is closed by the consumer, in which case it closes its input,
+//     propagating the shutdown upstream
+//
+// Inputs are used to their full extent when they implement the methods of
+// [atomic.CloseSafeChan] going beyond [atomic.Receiver] : IterContext to stop
+// waiting for values on cancellation, Err to report their cause, and Close to
+// be closed by the stage. Stages never leak goroutines as long as the pipeline
+// is either drained, closed from its end, or canceled through its context,
+// provided their inputs implement IterContext, as all the channels of the
+// atomic package do.
+package pipeline
+
+import (
+	"context"
+	"hash/maphash"
+	"io"
+	"iter"
+	"sync"
+
+	"github.com/wazazaby/gs/atomic"
+)
+
+// FromSeq sends the values of seq to a new [atomic.CloseSafeChan] of the given
+// buffer size, closing it once seq is exhausted.
+func FromSeq[T any](ctx context.Context, seq iter.Seq[T], size int) *atomic.CloseSafeChan[T] {
+	out := atomic.MakeCloseSafeChan[T](size)
+	go func() {
+		for v := range seq {
+			if out.SendContext(ctx, v) != nil {
+				break
+			}
+		}
+		_ = out.CloseWithError(ctx.Err())
+	}()
+	return out
+}
+
+// FromPull is like [FromSeq] for an iterator obtained from [iter.Pull], it
+// calls stop once done.
+func FromPull[T any](ctx context.Context, next func() (T, bool), stop func(), size int) *atomic.CloseSafeChan[T] {
+	return FromSeq(ctx, func(yield func(T) bool) {
+		defer stop()
+		for {
+			v, ok := next()
+			if !ok || !yield(v) {
+				return
+			}
+		}
+	}, size)
+}
+
+// Seq returns an iterator ranging on the values of in, until it is closed and
+// drained or ctx is done.
+//
+// For receivers without an IterContext method, ctx is only observed between
+// values.
+func Seq[T any](ctx context.Context, in atomic.Receiver[T]) iter.Seq[T] {
+	return iterContext(ctx, in)
+}
+
+// Pull converts in to a pull-style iterator, see [iter.Pull].
+func Pull[T any](ctx context.Context, in atomic.Receiver[T]) (next func() (T, bool), stop func()) {
+	return iter.Pull(Seq(ctx, in))
+}
+
+// Collect receives the values of in until it is closed and drained or ctx is
+// done, returning them with the cause of the stop : the context's error or the
+// cause of in's closing.
+func Collect[T any](ctx context.Context, in atomic.Receiver[T]) ([]T, error) {
+	var values []T
+	for v := range iterContext(ctx, in) {
+		values = append(values, v)
+	}
+	return values, cause(ctx, in)
+}
+
+// Map sends fn(v) for every value v of in.
+func Map[In, Out any](ctx context.Context, in atomic.Receiver[In], fn func(In) Out, size int) *atomic.CloseSafeChan[Out] {
+	out := atomic.MakeCloseSafeChan[Out](size)
+	go forward(ctx, in, out, func(v In, emit func(Out) bool) bool {
+		return emit(fn(v))
+	})
+	return out
+}
+
+// Filter sends the values of in for which keep returns true.
+func Filter[T any](ctx context.Context, in atomic.Receiver[T], keep func(T) bool, size int) *atomic.CloseSafeChan[T] {
+	out := atomic.MakeCloseSafeChan[T](size)
+	go forward(ctx, in, out, func(v T, emit func(T) bool) bool {
+		return !keep(v) || emit(v)
+	})
+	return out
+}
+
+// FlatMap sends all the values of fn(v) for every value v of in.
+func FlatMap[In, Out any](ctx context.Context, in atomic.Receiver[In], fn func(In) iter.Seq[Out], size int) *atomic.CloseSafeChan[Out] {
+	out := atomic.MakeCloseSafeChan[Out](size)
+	go forward(ctx, in, out, func(v In, emit func(Out) bool) bool {
+		for o := range fn(v) {
+			if !emit(o) {
+				return false
+			}
+		}
+		return true
+	})
+	return out
+}
+
+// MapN is like [Map], calling fn from the given number of goroutines.
+//
+// If ordered is true, values are sent in the order of in, at the cost of
+// head-of-line blocking behind slow calls.
+func MapN[In, Out any](ctx context.Context, in atomic.Receiver[In], workers int, fn func(In) Out, size int, ordered bool) *atomic.CloseSafeChan[Out] {
+	workers = max(workers, 1)
+	out := atomic.MakeCloseSafeChan[Out](size)
+	if ordered {
+		go mapOrdered(ctx, in, out, workers, fn)
+		return out
+	}
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			consume(ctx, in, out, func(v In, emit func(Out) bool) bool {
+				return emit(fn(v))
+			})
+		})
+	}
+	go func() {
+		wg.Wait()
+		_ = out.CloseWithError(cause(ctx, in))
+	}()
+	return out
+}
+
+// mapOrdered dispatches the values of in to the workers along with a future
+// queued in order, which are then awaited one after the other.
+func mapOrdered[In, Out any](ctx context.Context, in atomic.Receiver[In], out *atomic.CloseSafeChan[Out], workers int, fn func(In) Out) {
+	type job struct {
+		value  In
+		result chan Out
+	}
+	var (
+		jobs    = make(chan job, workers)
+		futures = make(chan chan Out, workers)
+		quit    = make(chan struct{})
+		wg      sync.WaitGroup
+	)
+	stageCtx, cancel := stageContext(ctx, out)
+	for range workers {
+		wg.Go(func() {
+			for j := range jobs {
+				j.result <- fn(j.value)
+			}
+		})
+	}
+	go func() {
+		defer close(futures)
+		defer close(jobs)
+		for v := range iterContext(stageCtx, in) {
+			j := job{value: v, result: make(chan Out, 1)}
+			select {
+			case futures <- j.result:
+			case <-quit:
+				return
+			}
+			jobs <- j
+		}
+	}()
+
+	defer func() {
+		close(quit)
+		cancel()
+		wg.Wait()
+		if out.IsClosed() {
+			closeInput(in)
+		}
+		_ = out.CloseWithError(cause(ctx, in))
+	}()
+	for f := range futures {
+		var v Out
+		select {
+		case v = <-f:
+		case <-stageCtx.Done():
+			return
+		}
+		if out.SendContext(ctx, v) != nil {
+			return
+		}
+	}
+}
+
+// Merge sends the values of all the inputs to a single output, which is closed
+// once all of them are closed and drained.
+//
+// The output is closed with the first non-nil cause of the inputs.
+func Merge[T any](ctx context.Context, size int, ins ...atomic.Receiver[T]) *atomic.CloseSafeChan[T] {
+	out := atomic.MakeCloseSafeChan[T](size)
+	var wg sync.WaitGroup
+	for _, in := range ins {
+		wg.Go(func() {
+			consume(ctx, in, out, func(v T, emit func(T) bool) bool {
+				return emit(v)
+			})
+		})
+	}
+	go func() {
+		wg.Wait()
+		var err error
+		for _, in := range ins {
+			if err = cause(ctx, in); err != nil {
+				break
+			}
+		}
+		_ = out.CloseWithError(err)
+	}()
+	return out
+}
+
+// Tee sends every value of in to n outputs.
+//
+// A slow output slows down all of them. An output closed by its consumer is
+// skipped, and in is closed once all of them are.
+func Tee[T any](ctx context.Context, in atomic.Receiver[T], n, size int) []*atomic.CloseSafeChan[T] {
+	outs := makeOutputs[T](n, size)
+	go fanOut(ctx, in, outs, func(v T, send func(int, T)) {
+		for i := range outs {
+			send(i, v)
+		}
+	})
+	return outs
+}
+
+// Partition sends every value of in to one of n outputs, selected by hashing
+// its key, so that values sharing a key are sent to the same output.
+//
+// Values routed to an output closed by its consumer are dropped, and in is
+// closed once all of them are.
+func Partition[T any, K comparable](ctx context.Context, in atomic.Receiver[T], n int, key func(T) K, size int) []*atomic.CloseSafeChan[T] {
+	outs := makeOutputs[T](n, size)
+	seed := maphash.MakeSeed()
+	go fanOut(ctx, in, outs, func(v T, send func(int, T)) {
+		send(int(maphash.Comparable(seed, key(v))%uint64(len(outs))), v)
+	})
+	return outs
+}
+
+func makeOutputs[T any](n, size int) []*atomic.CloseSafeChan[T] {
+	outs := make([]*atomic.CloseSafeChan[T], max(n, 1))
+	for i := range outs {
+		outs[i] = atomic.MakeCloseSafeChan[T](size)
+	}
+	return outs
+}
+
+// fanOut runs route over the values of in, with a send function delivering a
+// value to one of outs, until in is drained, ctx is done or all outs are
+// closed by their consumers.
+func fanOut[T any](ctx context.Context, in atomic.Receiver[T], outs []*atomic.CloseSafeChan[T], route func(T, func(int, T))) {
+	defer func() {
+		err := cause(ctx, in)
+		for _, out := range outs {
+			_ = out.CloseWithError(err)
+		}
+	}()
+	stageCtx, cancel := stageContext(ctx, outs...)
+	defer cancel()
+	send := func(i int, v T) {
+		_ = outs[i].SendContext(ctx, v)
+	}
+	for v := range iterContext(stageCtx, in) {
+		route(v, send)
+		if allClosed(outs) {
+			break
+		}
+	}
+	if allClosed(outs) {
+		closeInput(in)
+	}
+}
+
+func allClosed[T any](outs []*atomic.CloseSafeChan[T]) bool {
+	for _, out := range outs {
+		if !out.IsClosed() {
+			return false
+		}
+	}
+	return true
+}
+
+// forward runs [consume] and closes out once done.
+func forward[In, Out any](ctx context.Context, in atomic.Receiver[In], out *atomic.CloseSafeChan[Out], fn func(In, func(Out) bool) bool) {
+	consume(ctx, in, out, fn)
+	_ = out.CloseWithError(cause(ctx, in))
+}
+
+// consume runs fn over the values of in, with an emit function sending to out,
+// until in is drained, ctx is done, emit fails, or out is closed. If out was
+// closed by its consumer, in is closed.
+func consume[In, Out any](ctx context.Context, in atomic.Receiver[In], out *atomic.CloseSafeChan[Out], fn func(In, func(Out) bool) bool) {
+	stageCtx, cancel := stageContext(ctx, out)
+	defer cancel()
+	emit := func(v Out) bool {
+		return out.SendContext(ctx, v) == nil
+	}
+	for v := range iterContext(stageCtx, in) {
+		if !fn(v, emit) {
+			break
+		}
+	}
+	if out.IsClosed() {
+		closeInput(in)
+	}
+}
+
+// stageContext returns a context derived from ctx, also canceled once all the
+// outs are closed, so that a stage waiting for its input stops as soon as its
+// consumers are gone, rather than on its next emit.
+func stageContext[T any](ctx context.Context, outs ...*atomic.CloseSafeChan[T]) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		for _, out := range outs {
+			select {
+			case <-out.Done():
+			case <-ctx.Done():
+				return
+			}
+		}
+		cancel()
+	}()
+	return ctx, cancel
+}
+
+// iterContext ranges on the values of in until ctx is done.
+//
+// Receivers without an IterContext method only observe ctx between values.
+func iterContext[T any](ctx context.Context, in atomic.Receiver[T]) iter.Seq[T] {
+	if in, ok := in.(interface {
+		IterContext(context.Context) iter.Seq[T]
+	}); ok {
+		return in.IterContext(ctx)
+	}
+	return func(yield func(T) bool) {
+		for v := range in.Iter() {
+			if ctx.Err() != nil || !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// closeInput closes in, if it can be closed.
+func closeInput[T any](in atomic.Receiver[T]) {
+	if c, ok := in.(io.Closer); ok {
+		_ = c.Close()
+	}
+}
+
+// cause returns the reason a stage reading in stopped : the context's error, or
+// the cause of in's closing if it reports one.
+func cause[T any](ctx context.Context, in atomic.Receiver[T]) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if in, ok := in.(interface{ Err() error }); ok {
+		return in.Err()
+	}
+	return nil
+}
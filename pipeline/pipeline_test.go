@@ -0,0 +1,362 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"runtime"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wazazaby/gs/atomic"
+)
+
+// noLeak fails the test if goroutines started during the test are still
+// running once it's done.
+func noLeak(t *testing.T) {
+	t.Helper()
+	before := runtime.NumGoroutine()
+	t.Cleanup(func() {
+		// Polling by hand, as require.Eventually runs goroutines of its own.
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+			if runtime.NumGoroutine() <= before {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Fatalf("%d goroutines leaked", runtime.NumGoroutine()-before)
+	})
+}
+
+func TestMapFilterFlatMap(t *testing.T) {
+	noLeak(t)
+	ctx := context.Background()
+
+	in := FromSeq(ctx, slices.Values([]int{1, 2, 3, 4, 5, 6}), 0)
+	even := Filter(ctx, in, func(v int) bool { return v%2 == 0 }, 0)
+	doubled := FlatMap(ctx, even, func(v int) iter.Seq[int] {
+		return slices.Values([]int{v, v})
+	}, 0)
+	strs := Map(ctx, doubled, strconv.Itoa, 4)
+
+	got, err := Collect(ctx, strs)
+	require.NoError(t, err)
+	require.Equal(t, []string{"2", "2", "4", "4", "6", "6"}, got)
+}
+
+func TestMapPropagatesCause(t *testing.T) {
+	noLeak(t)
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	in := atomic.MakeCloseSafeChan[int](2)
+	require.True(t, in.Send(1))
+	require.NoError(t, in.CloseWithError(errAbort))
+
+	got, err := Collect(ctx, Map(ctx, in, func(v int) int { return v + 1 }, 0))
+	require.ErrorIs(t, err, errAbort)
+	require.Equal(t, []int{2}, got)
+}
+
+func TestMapCancel(t *testing.T) {
+	noLeak(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// An infinite source.
+	src := FromSeq(ctx, func(yield func(int) bool) {
+		for i := 0; yield(i); i++ {
+		}
+	}, 0)
+	out := Map(ctx, src, func(v int) int { return v }, 0)
+
+	for range 10 {
+		_, ok := out.Receive()
+		require.True(t, ok)
+	}
+	cancel()
+
+	for range out.Iter() {
+	}
+	require.ErrorIs(t, out.Err(), context.Canceled)
+}
+
+func TestMapCancelCondChans(t *testing.T) {
+	for name, src := range map[string]atomic.Receiver[int]{
+		"UnboundedChan": atomic.MakeUnboundedChan[int](),
+		"PriorityChan":  atomic.MakePriorityChan[int](2, 1),
+	} {
+		t.Run(name, func(t *testing.T) {
+			noLeak(t)
+			ctx, cancel := context.WithCancel(context.Background())
+			// The source stays idle, the stage waits for it when canceled.
+			out := Map(ctx, src, func(v int) int { return v }, 0)
+			cancel()
+
+			for range out.Iter() {
+			}
+			require.True(t, out.IsClosed())
+			require.ErrorIs(t, out.Err(), context.Canceled)
+		})
+	}
+}
+
+func TestCloseOutputPropagatesUpstream(t *testing.T) {
+	noLeak(t)
+	ctx := context.Background()
+
+	src := atomic.MakeCloseSafeChan[int]()
+	go func() {
+		for i := 0; src.Send(i); i++ {
+		}
+	}()
+	out := Map(ctx, Filter(ctx, src, func(int) bool { return true }, 0), func(v int) int { return v }, 0)
+
+	_, ok := out.Receive()
+	require.True(t, ok)
+	require.NoError(t, out.Close())
+
+	<-src.Done()
+}
+
+func TestCloseOutputIdleSource(t *testing.T) {
+	id := func(v int) int { return v }
+	stages := map[string]func(ctx context.Context, src *atomic.CloseSafeChan[int]) []*atomic.CloseSafeChan[int]{
+		"Map": func(ctx context.Context, src *atomic.CloseSafeChan[int]) []*atomic.CloseSafeChan[int] {
+			return []*atomic.CloseSafeChan[int]{Map(ctx, Filter(ctx, src, func(int) bool { return true }, 0), id, 0)}
+		},
+		"MapN": func(ctx context.Context, src *atomic.CloseSafeChan[int]) []*atomic.CloseSafeChan[int] {
+			return []*atomic.CloseSafeChan[int]{MapN(ctx, src, 4, id, 0, false)}
+		},
+		"MapNOrdered": func(ctx context.Context, src *atomic.CloseSafeChan[int]) []*atomic.CloseSafeChan[int] {
+			return []*atomic.CloseSafeChan[int]{MapN(ctx, src, 4, id, 0, true)}
+		},
+		"Merge": func(ctx context.Context, src *atomic.CloseSafeChan[int]) []*atomic.CloseSafeChan[int] {
+			return []*atomic.CloseSafeChan[int]{Merge[int](ctx, 0, src)}
+		},
+		"Tee": func(ctx context.Context, src *atomic.CloseSafeChan[int]) []*atomic.CloseSafeChan[int] {
+			return Tee(ctx, src, 2, 0)
+		},
+		"Partition": func(ctx context.Context, src *atomic.CloseSafeChan[int]) []*atomic.CloseSafeChan[int] {
+			return Partition(ctx, src, 2, id, 0)
+		},
+	}
+	for name, stage := range stages {
+		t.Run(name, func(t *testing.T) {
+			noLeak(t)
+			// The source never sends, the stage waits for it when the output
+			// is closed.
+			src := atomic.MakeCloseSafeChan[int]()
+			outs := stage(context.Background(), src)
+			for _, out := range outs {
+				require.NoError(t, out.Close())
+			}
+
+			select {
+			case <-src.Done():
+			case <-time.After(5 * time.Second):
+				t.Fatal("source not closed")
+			}
+		})
+	}
+}
+
+func TestMergeTee(t *testing.T) {
+	noLeak(t)
+	ctx := context.Background()
+
+	a := FromSeq(ctx, slices.Values([]int{1, 2, 3}), 0)
+	b := FromSeq(ctx, slices.Values([]int{4, 5}), 0)
+	outs := Tee(ctx, Merge(ctx, 0, a, b), 2, 8)
+	require.Len(t, outs, 2)
+
+	for _, out := range outs {
+		got, err := Collect(ctx, out)
+		require.NoError(t, err)
+		slices.Sort(got)
+		require.Equal(t, []int{1, 2, 3, 4, 5}, got)
+	}
+}
+
+func TestTeeSkipsClosedOutputs(t *testing.T) {
+	noLeak(t)
+	ctx := context.Background()
+
+	outs := Tee(ctx, FromSeq(ctx, slices.Values([]int{1, 2, 3}), 0), 2, 0)
+	require.NoError(t, outs[0].Close())
+
+	got, err := Collect(ctx, outs[1])
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 3}, got)
+}
+
+func TestPartition(t *testing.T) {
+	noLeak(t)
+	ctx := context.Background()
+
+	words := []string{"apple", "avocado", "banana", "blueberry", "cherry", "apricot", "coconut"}
+	outs := Partition(ctx, FromSeq(ctx, slices.Values(words), 0), 3, func(s string) byte { return s[0] }, len(words))
+
+	var total int
+	owner := make(map[byte]int)
+	for i, out := range outs {
+		got, err := Collect(ctx, out)
+		require.NoError(t, err)
+		for _, w := range got {
+			if o, ok := owner[w[0]]; ok {
+				require.Equal(t, o, i, "key %q split across outputs", w[0])
+			}
+			owner[w[0]] = i
+		}
+		total += len(got)
+	}
+	require.Equal(t, len(words), total)
+}
+
+func TestMapN(t *testing.T) {
+	const n = 200
+
+	for _, ordered := range []bool{false, true} {
+		t.Run("ordered="+strconv.FormatBool(ordered), func(t *testing.T) {
+			noLeak(t)
+			ctx := context.Background()
+
+			in := FromSeq(ctx, func(yield func(int) bool) {
+				for i := range n {
+					if !yield(i) {
+						return
+					}
+				}
+			}, 0)
+			out := MapN(ctx, in, 8, func(v int) int {
+				// Later values complete first.
+				time.Sleep(time.Duration(n-v) * time.Microsecond)
+				return v * 2
+			}, 0, ordered)
+
+			got, err := Collect(ctx, out)
+			require.NoError(t, err)
+			require.Len(t, got, n)
+			if !ordered {
+				slices.Sort(got)
+			}
+			for i, v := range got {
+				require.Equal(t, i*2, v)
+			}
+		})
+	}
+}
+
+func TestMapNCancel(t *testing.T) {
+	for _, ordered := range []bool{false, true} {
+		t.Run("ordered="+strconv.FormatBool(ordered), func(t *testing.T) {
+			noLeak(t)
+			ctx, cancel := context.WithCancel(context.Background())
+
+			src := FromSeq(ctx, func(yield func(int) bool) {
+				for i := 0; yield(i); i++ {
+				}
+			}, 0)
+			out := MapN(ctx, src, 4, func(v int) int { return v }, 0, ordered)
+
+			for range 10 {
+				_, ok := out.Receive()
+				require.True(t, ok)
+			}
+			cancel()
+			for range out.Iter() {
+			}
+			require.ErrorIs(t, out.Err(), context.Canceled)
+		})
+	}
+}
+
+func TestMapNCloseOutput(t *testing.T) {
+	for _, ordered := range []bool{false, true} {
+		t.Run("ordered="+strconv.FormatBool(ordered), func(t *testing.T) {
+			noLeak(t)
+			ctx := context.Background()
+
+			src := atomic.MakeCloseSafeChan[int]()
+			go func() {
+				for i := 0; src.Send(i); i++ {
+				}
+			}()
+			out := MapN(ctx, src, 4, func(v int) int { return v }, 0, ordered)
+
+			_, ok := out.Receive()
+			require.True(t, ok)
+			require.NoError(t, out.Close())
+
+			<-src.Done()
+		})
+	}
+}
+
+func TestPull(t *testing.T) {
+	noLeak(t)
+	ctx := context.Background()
+
+	next, stop := Pull(ctx, FromSeq(ctx, slices.Values([]int{1, 2, 3}), 0))
+	out := FromPull(ctx, next, stop, 0)
+
+	got, err := Collect(ctx, out)
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 3}, got)
+}
+
+// sliceReceiver is an in-memory [atomic.Receiver] fake.
+type sliceReceiver[T any] struct {
+	values []T
+}
+
+func (r *sliceReceiver[T]) Receive() (T, bool) {
+	if len(r.values) == 0 {
+		return *new(T), false
+	}
+	v := r.values[0]
+	r.values = r.values[1:]
+	return v, true
+}
+
+func (r *sliceReceiver[T]) Iter() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			v, ok := r.Receive()
+			if !ok || !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func TestReceiverImplementations(t *testing.T) {
+	noLeak(t)
+	ctx := context.Background()
+
+	unbounded := atomic.MakeUnboundedChan[int]()
+	priority := atomic.MakePriorityChan[int](2, 4)
+	for i := range 3 {
+		require.True(t, unbounded.Send(i))
+		require.True(t, priority.Lane(i%2).Send(10+i))
+	}
+	require.NoError(t, unbounded.Close())
+	require.NoError(t, priority.Close())
+
+	view := atomic.MakeCloseSafeChan[int](2)
+	require.True(t, view.Send(20))
+	require.NoError(t, view.Close())
+
+	merged := Merge(ctx, 0,
+		unbounded,
+		priority.Receiver(),
+		view.Receiver(),
+		&sliceReceiver[int]{values: []int{30, 31}},
+	)
+	got, err := Collect(ctx, Map(ctx, merged, func(v int) int { return v }, 0))
+	require.NoError(t, err)
+	slices.Sort(got)
+	require.Equal(t, []int{0, 1, 2, 10, 11, 12, 20, 30, 31}, got)
+}
@@ -0,0 +1,43 @@
+package disk
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// Codec converts the items of a [Queue] to and from their on-disk form.
+//
+// Unmarshal must not retain data, which is reused by the [Queue].
+type Codec[T any] interface {
+	Marshal(item T) ([]byte, error)
+	Unmarshal(data []byte) (T, error)
+}
+
+// JSONCodec is a [Codec] using [encoding/json].
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Marshal(item T) ([]byte, error) {
+	return json.Marshal(item)
+}
+
+func (JSONCodec[T]) Unmarshal(data []byte) (T, error) {
+	var item T
+	err := json.Unmarshal(data, &item)
+	return item, err
+}
+
+// BytesCodec is a [Codec] storing byte slices as is.
+type BytesCodec struct{}
+
+func (BytesCodec) Marshal(item []byte) ([]byte, error) {
+	return item, nil
+}
+
+func (BytesCodec) Unmarshal(data []byte) ([]byte, error) {
+	return slices.Clone(data), nil
+}
+
+var (
+	_ Codec[any]    = JSONCodec[any]{}
+	_ Codec[[]byte] = BytesCodec{}
+)
@@ -0,0 +1,539 @@
+// Package disk implements a durable queue, surviving process restarts, with an
+// API mirroring [atomic.CloseSafeChan].
+//
+// Items are encoded by a pluggable [Codec] and appended to segment files as
+// checksummed records. The consumer position is persisted by [Queue.Ack] :
+// after a restart, delivery resumes from the last acknowledged item, so items
+// are delivered at least once. Segments whose items are all acknowledged are
+// deleted.
+//
+// A queue directory must be used by a single [Queue] at a time.
+package disk
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/wazazaby/gs/atomic"
+)
+
+const (
+	// DefaultSegmentSize is the size after which a segment is sealed and a
+	// new one started.
+	DefaultSegmentSize = 64 << 20
+
+	ackFile = "ack"
+)
+
+// SyncPolicy selects when the active segment is flushed to stable storage with
+// fsync. Segments are always flushed when sealed and on [Queue.Close].
+type SyncPolicy struct {
+	// Every flushes after that many items, 0 disabling it.
+	Every int
+	// Interval flushes on the first item sent at least Interval after the
+	// previous flush, 0 disabling it.
+	Interval time.Duration
+}
+
+var (
+	// SyncAlways flushes every item before [Queue.Send] returns, this is the
+	// default policy.
+	SyncAlways = SyncPolicy{Every: 1}
+	// SyncNever leaves flushing to the operating system, a crash may lose the
+	// latest items.
+	SyncNever = SyncPolicy{}
+)
+
+// Option configures a [Queue], see [Open].
+type Option func(*options)
+
+type options struct {
+	segmentSize int64
+	sync        SyncPolicy
+}
+
+// WithSegmentSize sets the size after which a segment is sealed, see
+// [DefaultSegmentSize].
+func WithSegmentSize(n int64) Option {
+	return func(o *options) { o.segmentSize = n }
+}
+
+// WithSync sets the [SyncPolicy] of the [Queue].
+func WithSync(p SyncPolicy) Option {
+	return func(o *options) { o.sync = p }
+}
+
+// Queue is a durable FIFO queue of items of type T stored in a directory.
+//
+// Like an [atomic.CloseSafeChan], it is safe to send, receive and close from
+// concurrently running goroutines : once closed, send operations report it by
+// returning false, and receivers drain the remaining items before observing
+// the [Queue] as closed. Items not received before closing stay on disk, and
+// are delivered after the next [Open].
+type Queue[T any] struct {
+	dir   string
+	codec Codec[T]
+	opts  options
+
+	mu sync.Mutex
+	// nonEmpty is signaled when an item is sent, and broadcast on close.
+	nonEmpty sync.Cond
+	segments []segment
+	closed   bool
+	err      error
+
+	// Writing side : the last segment.
+	writer   *os.File
+	writeBuf []byte
+	next     uint64
+	unsynced int
+	syncedAt time.Time
+
+	// Reading side : the record of sequence number readSeq, found at readOff
+	// in the segment of index readSeg.
+	reader  *os.File
+	readSeg int
+	readOff int64
+	readSeq uint64
+	readBuf []byte
+
+	// acked is the sequence number of the first item not acknowledged.
+	acked uint64
+	// undecodable counts the items the codec failed to decode.
+	undecodable uint64
+}
+
+// Open opens the [Queue] stored in dir, creating it if needed.
+//
+// A torn write at the end of the last segment, left by a crash, is truncated.
+// Damages anywhere else are reported as [ErrCorrupt]. Items lost by a crash
+// despite being acknowledged, which the [SyncPolicy] allows, are ignored.
+func Open[T any](dir string, codec Codec[T], opts ...Option) (*Queue[T], error) {
+	o := options{
+		segmentSize: DefaultSegmentSize,
+		sync:        SyncAlways,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range segments {
+		if i > 0 && segments[i].first != segments[i-1].end() {
+			return nil, fmt.Errorf("%w: missing records %d to %d", ErrCorrupt, segments[i-1].end(), segments[i].first)
+		}
+		if err := scanSegment(dir, &segments[i], i == len(segments)-1); err != nil {
+			return nil, err
+		}
+	}
+	acked, err := readAck(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		segments = append(segments, segment{first: acked})
+	}
+	first, last := segments[0], segments[len(segments)-1]
+	acked = min(max(acked, first.first), last.end())
+
+	writer, err := os.OpenFile(last.path(dir), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syncDir(dir); err != nil {
+		_ = writer.Close()
+		return nil, err
+	}
+	q := &Queue[T]{
+		dir:      dir,
+		codec:    codec,
+		opts:     o,
+		segments: segments,
+		writer:   writer,
+		next:     last.end(),
+		syncedAt: time.Now(),
+		acked:    acked,
+	}
+	q.nonEmpty.L = &q.mu
+	if err := q.seek(acked); err != nil {
+		_ = writer.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+// Close closes the [Queue], flushing the active segment and releasing its
+// files. Receiving the remaining items reopens the segments being read.
+//
+// It is safe to call it from concurrently running goroutines, only the first
+// call returns an error if the flush fails.
+func (q *Queue[T]) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	q.nonEmpty.Broadcast()
+	err := q.sync()
+	if cerr := q.writer.Close(); err == nil {
+		err = cerr
+	}
+	q.closeReader()
+	return err
+}
+
+// Send appends an item to the [Queue].
+//
+// It returns true if the item was stored, according to the [SyncPolicy], and
+// false if the [Queue] is closed or the item couldn't be stored, see
+// [Queue.Put].
+func (q *Queue[T]) Send(item T) bool {
+	return q.Put(item) == nil
+}
+
+// Put is like [Queue.Send], but returns why the item wasn't stored :
+// [atomic.ErrClosed] if the [Queue] is closed, a [Codec] error, or an I/O
+// error. The [Queue] is closed after an I/O error, see [Queue.Err].
+func (q *Queue[T]) Put(item T) error {
+	payload, err := q.codec.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return atomic.ErrClosed
+	}
+	q.writeBuf = encodeRecord(q.writeBuf[:0], payload)
+	if _, err := q.writer.Write(q.writeBuf); err != nil {
+		q.fail(err)
+		return err
+	}
+	active := &q.segments[len(q.segments)-1]
+	active.count++
+	active.size += int64(len(q.writeBuf))
+	q.next++
+	q.nonEmpty.Signal()
+
+	q.unsynced++
+	p := q.opts.sync
+	if p.Every > 0 && q.unsynced >= p.Every || p.Interval > 0 && time.Since(q.syncedAt) >= p.Interval {
+		if err := q.sync(); err != nil {
+			q.fail(err)
+			return err
+		}
+	}
+	if active.size >= q.opts.segmentSize {
+		if err := q.rotate(); err != nil {
+			q.fail(err)
+			return err
+		}
+	}
+	return nil
+}
+
+// Receive receives the next item of the [Queue], blocking until one is sent.
+//
+// It returns the item, and a bool indicating if the [Queue] is open (true) or
+// closed and drained (false). Received items are delivered again after the
+// next [Open] unless acknowledged with [Queue.Ack].
+//
+// Items the [Codec] fails to decode are skipped, and counted by
+// [Queue.Undecodable]. Use [Queue.Get] to handle them.
+func (q *Queue[T]) Receive() (T, bool) {
+	for {
+		item, err := q.Get()
+		if err == nil {
+			return item, true
+		}
+		if !errors.Is(err, ErrDecode) {
+			return *new(T), false
+		}
+	}
+}
+
+// Get is like [Queue.Receive], but returns why no item was received :
+//   - an error wrapping [ErrDecode] and the [Codec] error if the next item
+//     can't be decoded, in which case it is received all the same, so that it
+//     can be acknowledged, and the [Queue] stays usable
+//   - [atomic.ErrClosed] if the [Queue] is closed and drained
+//   - the I/O error that closed the [Queue], see [Queue.Err]
+func (q *Queue[T]) Get() (T, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.readSeq == q.next && !q.closed {
+		q.nonEmpty.Wait()
+	}
+	if q.readSeq == q.next {
+		q.closeReader()
+		if q.err != nil {
+			return *new(T), q.err
+		}
+		return *new(T), atomic.ErrClosed
+	}
+	if q.reader == nil {
+		// Closed by a read error, or by Close.
+		if q.err != nil {
+			return *new(T), q.err
+		}
+		if err := q.seek(q.readSeq); err != nil {
+			q.fail(err)
+			return *new(T), err
+		}
+	}
+	payload, err := q.read()
+	if err != nil {
+		q.fail(err)
+		q.closeReader()
+		return *new(T), err
+	}
+	item, err := q.codec.Unmarshal(payload)
+	if err != nil {
+		q.undecodable++
+		return *new(T), fmt.Errorf("%w %d: %w", ErrDecode, q.readSeq-1, err)
+	}
+	return item, nil
+}
+
+// Iter returns an iterator ranging on the items of the [Queue], until it is
+// closed and drained.
+func (q *Queue[T]) Iter() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			item, ok := q.Receive()
+			if !ok || !yield(item) {
+				return
+			}
+		}
+	}
+}
+
+// Ack acknowledges all the items received so far, so that they aren't
+// delivered again after the next [Open], and deletes the segments holding only
+// acknowledged items.
+//
+// The active segment is flushed first, whatever the [SyncPolicy], so that the
+// acknowledged position never gets beyond the items surviving a crash.
+func (q *Queue[T]) Ack() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.readSeq == q.acked {
+		return nil
+	}
+	if q.unsynced > 0 {
+		if err := q.sync(); err != nil {
+			q.fail(err)
+			return err
+		}
+	}
+	if err := writeAck(q.dir, q.readSeq); err != nil {
+		return err
+	}
+	q.acked = q.readSeq
+	return q.compact()
+}
+
+// Len returns the number of items not received yet.
+func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return int(q.next - q.readSeq)
+}
+
+// Undecodable returns the number of items the [Codec] failed to decode since
+// [Open].
+func (q *Queue[T]) Undecodable() uint64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.undecodable
+}
+
+// Err returns the I/O error that closed the [Queue], if any.
+func (q *Queue[T]) Err() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.err
+}
+
+// fail closes the [Queue] after an I/O error, the caller must hold the lock.
+func (q *Queue[T]) fail(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+	if !q.closed {
+		q.closed = true
+		_ = q.writer.Close()
+		q.nonEmpty.Broadcast()
+	}
+}
+
+func (q *Queue[T]) sync() error {
+	if err := q.writer.Sync(); err != nil {
+		return err
+	}
+	q.unsynced = 0
+	q.syncedAt = time.Now()
+	return nil
+}
+
+// rotate seals the active segment and starts a new one.
+func (q *Queue[T]) rotate() error {
+	if err := q.sync(); err != nil {
+		return err
+	}
+	if err := q.writer.Close(); err != nil {
+		return err
+	}
+	s := segment{first: q.next}
+	writer, err := os.OpenFile(s.path(q.dir), os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	q.writer = writer
+	q.segments = append(q.segments, s)
+	return syncDir(q.dir)
+}
+
+// seek positions the reading side on the record of sequence number seq.
+func (q *Queue[T]) seek(seq uint64) error {
+	i := len(q.segments) - 1
+	for j, s := range q.segments {
+		if seq < s.end() {
+			i = j
+			break
+		}
+	}
+	if err := q.openReader(i); err != nil {
+		return err
+	}
+	for q.readSeq < seq {
+		_, n, err := readRecord(q.reader, q.readOff, q.readBuf)
+		if err != nil {
+			q.closeReader()
+			return err
+		}
+		q.readOff += n
+		q.readSeq++
+	}
+	return nil
+}
+
+func (q *Queue[T]) openReader(i int) error {
+	q.closeReader()
+	f, err := os.Open(q.segments[i].path(q.dir))
+	if err != nil {
+		return err
+	}
+	q.reader = f
+	q.readSeg = i
+	q.readOff = 0
+	q.readSeq = q.segments[i].first
+	return nil
+}
+
+func (q *Queue[T]) closeReader() {
+	if q.reader != nil {
+		_ = q.reader.Close()
+		q.reader = nil
+	}
+}
+
+// read returns the payload of the record of sequence number readSeq, moving to
+// the next segment if needed. The payload is only valid until the next call.
+func (q *Queue[T]) read() ([]byte, error) {
+	if q.readSeq == q.segments[q.readSeg].end() {
+		if err := q.openReader(q.readSeg + 1); err != nil {
+			return nil, err
+		}
+	}
+	payload, n, err := readRecord(q.reader, q.readOff, q.readBuf)
+	if err != nil {
+		return nil, err
+	}
+	q.readBuf = payload
+	q.readOff += n
+	q.readSeq++
+	return payload, nil
+}
+
+// compact deletes the segments holding only acknowledged items, except the
+// active one and the one being read.
+func (q *Queue[T]) compact() error {
+	var n int
+	for n < q.readSeg && n < len(q.segments)-1 && q.segments[n].end() <= q.acked {
+		if err := os.Remove(q.segments[n].path(q.dir)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	q.segments = q.segments[n:]
+	q.readSeg -= n
+	return syncDir(q.dir)
+}
+
+// readAck reads the persisted acknowledged position, 0 if there is none.
+func readAck(dir string) (uint64, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ackFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(data) != 12 || crc32.Checksum(data[:8], crcTable) != binary.LittleEndian.Uint32(data[8:]) {
+		return 0, fmt.Errorf("%w: damaged %s file", ErrCorrupt, ackFile)
+	}
+	return binary.LittleEndian.Uint64(data), nil
+}
+
+// writeAck atomically replaces the persisted acknowledged position.
+func writeAck(dir string, seq uint64) error {
+	data := binary.LittleEndian.AppendUint64(nil, seq)
+	data = binary.LittleEndian.AppendUint32(data, crc32.Checksum(data, crcTable))
+
+	tmp := filepath.Join(dir, ackFile+".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, ackFile)); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+var (
+	_ io.Closer            = &Queue[any]{}
+	_ atomic.Sender[any]   = &Queue[any]{}
+	_ atomic.Receiver[any] = &Queue[any]{}
+)
@@ -0,0 +1,325 @@
+package disk
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wazazaby/gs/atomic"
+)
+
+func openInts(t *testing.T, dir string, opts ...Option) *Queue[int] {
+	t.Helper()
+	q, err := Open(dir, JSONCodec[int]{}, opts...)
+	require.NoError(t, err)
+	return q
+}
+
+func sendInts(t *testing.T, q *Queue[int], from, to int) {
+	t.Helper()
+	for i := from; i < to; i++ {
+		require.NoError(t, q.Put(i))
+	}
+}
+
+func receiveInts(t *testing.T, q *Queue[int], n int) []int {
+	t.Helper()
+	var got []int
+	for range n {
+		v, ok := q.Receive()
+		require.True(t, ok)
+		got = append(got, v)
+	}
+	return got
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	require.NoError(t, err)
+	return files
+}
+
+func TestQueueSendReceive(t *testing.T) {
+	q := openInts(t, t.TempDir())
+	sendInts(t, q, 0, 10)
+	require.Equal(t, 10, q.Len())
+	require.Equal(t, []int{0, 1, 2}, receiveInts(t, q, 3))
+	require.NoError(t, q.Close())
+	require.NoError(t, q.Close())
+
+	require.False(t, q.Send(10))
+	require.ErrorIs(t, q.Put(10), atomic.ErrClosed)
+	require.Equal(t, []int{3, 4, 5, 6, 7, 8, 9}, slices.Collect(q.Iter()))
+	_, ok := q.Receive()
+	require.False(t, ok)
+	require.NoError(t, q.Err())
+}
+
+func TestQueueReceiveBlocks(t *testing.T) {
+	q := openInts(t, t.TempDir())
+	got := make(chan int)
+	go func() {
+		defer close(got)
+		for v := range q.Iter() {
+			got <- v
+		}
+	}()
+	sendInts(t, q, 0, 3)
+	require.Equal(t, 0, <-got)
+	require.Equal(t, 1, <-got)
+	require.Equal(t, 2, <-got)
+	require.NoError(t, q.Close())
+	_, ok := <-got
+	require.False(t, ok)
+}
+
+func TestQueueCloseReleasesReader(t *testing.T) {
+	dir := t.TempDir()
+	q := openInts(t, dir, WithSegmentSize(18))
+	sendInts(t, q, 0, 5)
+	require.Equal(t, []int{0}, receiveInts(t, q, 1))
+	require.NoError(t, q.Close())
+	require.Nil(t, q.reader)
+
+	// Draining reopens the segments, and releases them again.
+	require.Equal(t, []int{1, 2, 3, 4}, slices.Collect(q.Iter()))
+	require.Nil(t, q.reader)
+}
+
+func TestQueueRedeliversUnacked(t *testing.T) {
+	dir := t.TempDir()
+	q := openInts(t, dir)
+	sendInts(t, q, 0, 5)
+	receiveInts(t, q, 2)
+	require.NoError(t, q.Ack())
+	receiveInts(t, q, 2)
+	require.NoError(t, q.Close())
+
+	q = openInts(t, dir)
+	require.Equal(t, 3, q.Len())
+	sendInts(t, q, 5, 6)
+	require.NoError(t, q.Close())
+	require.Equal(t, []int{2, 3, 4, 5}, slices.Collect(q.Iter()))
+}
+
+func TestQueueCompaction(t *testing.T) {
+	dir := t.TempDir()
+	// Each record holds a single digit, 9 bytes : segments hold 2 records.
+	q := openInts(t, dir, WithSegmentSize(18), WithSync(SyncNever))
+	sendInts(t, q, 0, 9)
+	require.Len(t, segmentFiles(t, dir), 5)
+
+	require.Equal(t, []int{0, 1, 2, 3, 4}, receiveInts(t, q, 5))
+	require.NoError(t, q.Ack())
+	// The segment being read (4, 5) is kept.
+	require.Len(t, segmentFiles(t, dir), 3)
+
+	require.Equal(t, []int{5, 6, 7, 8}, receiveInts(t, q, 4))
+	require.NoError(t, q.Ack())
+	// The active segment is kept, even empty.
+	require.Len(t, segmentFiles(t, dir), 1)
+	require.NoError(t, q.Close())
+
+	q = openInts(t, dir, WithSegmentSize(18))
+	require.Equal(t, 0, q.Len())
+	sendInts(t, q, 9, 11)
+	require.NoError(t, q.Close())
+	require.Equal(t, []int{9, 10}, slices.Collect(q.Iter()))
+}
+
+func TestQueueTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	q := openInts(t, dir)
+	sendInts(t, q, 0, 3)
+	require.NoError(t, q.Close())
+
+	files := segmentFiles(t, dir)
+	require.Len(t, files, 1)
+	f, err := os.OpenFile(files[0], os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	// A header announcing 100 bytes, followed by a few of them.
+	_, err = f.Write(encodeRecord(nil, make([]byte, 100))[:20])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	q = openInts(t, dir)
+	sendInts(t, q, 3, 5)
+	require.NoError(t, q.Close())
+	require.Equal(t, []int{0, 1, 2, 3, 4}, slices.Collect(q.Iter()))
+}
+
+func TestQueueLostAfterAck(t *testing.T) {
+	dir := t.TempDir()
+	q := openInts(t, dir, WithSync(SyncNever))
+	sendInts(t, q, 0, 5)
+	require.Equal(t, []int{0, 1, 2, 3, 4}, receiveInts(t, q, 5))
+	require.NoError(t, q.Ack())
+	// The acknowledged items were flushed.
+	require.Zero(t, q.unsynced)
+	require.NoError(t, q.Close())
+
+	// A crash losing the tail of the segment anyway, 9 bytes per record.
+	files := segmentFiles(t, dir)
+	require.Len(t, files, 1)
+	require.NoError(t, os.Truncate(files[0], 18))
+
+	q = openInts(t, dir, WithSync(SyncNever))
+	require.Equal(t, 0, q.Len())
+	sendInts(t, q, 5, 7)
+	require.NoError(t, q.Close())
+	require.Equal(t, []int{5, 6}, slices.Collect(q.Iter()))
+}
+
+func TestQueueCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	q := openInts(t, dir, WithSegmentSize(18))
+	sendInts(t, q, 0, 6)
+	require.NoError(t, q.Close())
+
+	files := segmentFiles(t, dir)
+	require.Len(t, files, 4)
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	data[len(data)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(files[0], data, 0o644))
+	_, err = Open(dir, JSONCodec[int]{})
+	require.ErrorIs(t, err, ErrCorrupt)
+
+	dir = t.TempDir()
+	q = openInts(t, dir, WithSegmentSize(18))
+	sendInts(t, q, 0, 6)
+	require.NoError(t, q.Close())
+	require.NoError(t, os.Remove(segmentFiles(t, dir)[1]))
+	_, err = Open(dir, JSONCodec[int]{})
+	require.ErrorIs(t, err, ErrCorrupt)
+}
+
+// pickyCodec fails to decode "bad".
+type pickyCodec struct{ JSONCodec[string] }
+
+var errBad = errors.New("bad item")
+
+func (c pickyCodec) Unmarshal(data []byte) (string, error) {
+	s, err := c.JSONCodec.Unmarshal(data)
+	if s == "bad" {
+		return "", errBad
+	}
+	return s, err
+}
+
+func TestQueueUndecodable(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open[string](dir, pickyCodec{})
+	require.NoError(t, err)
+	for _, s := range []string{"a", "bad", "c", "bad", "e"} {
+		require.True(t, q.Send(s))
+	}
+
+	v, err := q.Get()
+	require.NoError(t, err)
+	require.Equal(t, "a", v)
+	_, err = q.Get()
+	require.ErrorIs(t, err, ErrDecode)
+	require.ErrorIs(t, err, errBad)
+	// The queue stays usable.
+	require.True(t, q.Send("f"))
+	v, ok := q.Receive()
+	require.True(t, ok)
+	require.Equal(t, "c", v)
+	// Receive skips them.
+	v, ok = q.Receive()
+	require.True(t, ok)
+	require.Equal(t, "e", v)
+	require.Equal(t, uint64(2), q.Undecodable())
+	require.NoError(t, q.Err())
+
+	// Acknowledged, they aren't delivered again.
+	require.NoError(t, q.Ack())
+	require.NoError(t, q.Close())
+	_, err = q.Get()
+	require.NoError(t, err)
+	_, err = q.Get()
+	require.ErrorIs(t, err, atomic.ErrClosed)
+
+	q, err = Open[string](dir, pickyCodec{})
+	require.NoError(t, err)
+	require.NoError(t, q.Close())
+	require.Equal(t, []string{"f"}, slices.Collect(q.Iter()))
+}
+
+func TestQueueBytesCodec(t *testing.T) {
+	q, err := Open(t.TempDir(), BytesCodec{})
+	require.NoError(t, err)
+	require.True(t, q.Send([]byte("foo")))
+	require.True(t, q.Send([]byte("quux")))
+	require.NoError(t, q.Close())
+	got := slices.Collect(q.Iter())
+	require.Equal(t, [][]byte{[]byte("foo"), []byte("quux")}, got)
+}
+
+func TestQueueConcurrent(t *testing.T) {
+	const (
+		senders = 4
+		items   = 200
+	)
+	q := openInts(t, t.TempDir(), WithSegmentSize(256), WithSync(SyncPolicy{Every: 16}))
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		got []int
+	)
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := range q.Iter() {
+				mu.Lock()
+				got = append(got, v)
+				mu.Unlock()
+			}
+		}()
+	}
+	var sends sync.WaitGroup
+	for s := range senders {
+		sends.Add(1)
+		go func() {
+			defer sends.Done()
+			for i := range items {
+				require.True(t, q.Send(s*items+i))
+			}
+		}()
+	}
+	sends.Wait()
+	require.NoError(t, q.Close())
+	wg.Wait()
+
+	slices.Sort(got)
+	want := make([]int, senders*items)
+	for i := range want {
+		want[i] = i
+	}
+	require.Equal(t, want, got)
+	require.NoError(t, q.Ack())
+}
+
+func BenchmarkQueueSend(b *testing.B) {
+	for _, policy := range []SyncPolicy{SyncNever, {Every: 64}} {
+		b.Run("every="+strconv.Itoa(policy.Every), func(b *testing.B) {
+			q, err := Open(b.TempDir(), BytesCodec{}, WithSync(policy))
+			require.NoError(b, err)
+			defer q.Close()
+			payload := make([]byte, 128)
+			b.SetBytes(int64(len(payload)))
+			for b.Loop() {
+				q.Send(payload)
+			}
+		})
+	}
+}
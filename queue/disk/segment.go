@@ -0,0 +1,168 @@
+package disk
+
+import (
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrCorrupt is returned by [Open] when the queue files are damaged beyond a
+// torn write at the end of the last segment.
+var ErrCorrupt = errors.New("disk: corrupt queue")
+
+// ErrDecode is wrapped by the errors of [Queue.Get] for items the [Codec]
+// failed to decode.
+var ErrDecode = errors.New("disk: undecodable item")
+
+const (
+	segmentExt = ".seg"
+	// headerSize is the size of a record header : the payload length and its
+	// CRC-32C checksum, both little-endian uint32.
+	headerSize = 8
+	// maxRecordSize bounds the payload length read from a header, so that a
+	// damaged header can't trigger a huge allocation.
+	maxRecordSize = 1 << 30
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// segment is a file holding count consecutive records, starting with the
+// record of sequence number first.
+type segment struct {
+	first uint64
+	count uint64
+	size  int64
+}
+
+func (s segment) path(dir string) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", s.first, segmentExt))
+}
+
+// end returns the sequence number following the last record of the segment.
+func (s segment) end() uint64 {
+	return s.first + s.count
+}
+
+// encodeRecord appends the record holding payload to dst.
+func encodeRecord(dst, payload []byte) []byte {
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(len(payload)))
+	dst = binary.LittleEndian.AppendUint32(dst, crc32.Checksum(payload, crcTable))
+	return append(dst, payload...)
+}
+
+// readRecord reads the record starting at off in f, returning its payload
+// (reusing buf when large enough) and its total size.
+//
+// It returns [io.ErrUnexpectedEOF] or [ErrCorrupt] for incomplete or damaged
+// records, and [io.EOF] if off is the end of f.
+func readRecord(f *os.File, off int64, buf []byte) ([]byte, int64, error) {
+	var header [headerSize]byte
+	if n, err := f.ReadAt(header[:], off); err != nil {
+		if err == io.EOF && n > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, 0, err
+	}
+	size := binary.LittleEndian.Uint32(header[:4])
+	if size > maxRecordSize {
+		return nil, 0, ErrCorrupt
+	}
+	payload := slices.Grow(buf[:0], int(size))[:size]
+	if _, err := f.ReadAt(payload, off+headerSize); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, 0, err
+	}
+	if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(header[4:]) {
+		return nil, 0, ErrCorrupt
+	}
+	return payload, headerSize + int64(size), nil
+}
+
+// listSegments returns the segments found in dir, sorted, without their
+// records counted.
+func listSegments(dir string) ([]segment, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var segments []segment
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), segmentExt)
+		if !ok || e.IsDir() {
+			continue
+		}
+		first, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, segment{first: first})
+	}
+	slices.SortFunc(segments, func(a, b segment) int {
+		return cmp.Compare(a.first, b.first)
+	})
+	return segments, nil
+}
+
+// scanSegment counts the valid records of s.
+//
+// If truncate is true, a damaged or incomplete record and everything after it
+// are considered a torn write and cut off the file. Otherwise they are
+// reported as [ErrCorrupt].
+func scanSegment(dir string, s *segment, truncate bool) error {
+	f, err := os.OpenFile(s.path(dir), os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var (
+		off int64
+		buf []byte
+	)
+	for {
+		payload, n, err := readRecord(f, off, buf)
+		if err == io.EOF {
+			break
+		}
+		if err == io.ErrUnexpectedEOF || err == ErrCorrupt {
+			if !truncate {
+				return fmt.Errorf("%w: damaged record %d of %s", ErrCorrupt, s.count, s.path(dir))
+			}
+			if err := f.Truncate(off); err != nil {
+				return err
+			}
+			if err := f.Sync(); err != nil {
+				return err
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+		buf = payload
+		off += n
+		s.count++
+	}
+	s.size = off
+	return nil
+}
+
+// syncDir makes the creation, renaming and removal of files in dir durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}